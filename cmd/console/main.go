@@ -1,10 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/kettari/location-bot/internal/console"
 	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
 )
 
 type Commands []console.Command
@@ -28,18 +31,30 @@ func initCommands() *Commands {
 		console.NewHelpCommand(),
 		console.NewScheduleFetchCommand(),
 		console.NewScheduleReportFullCommand(),
+		console.NewScheduleReportUnnotifiedCommand(),
+		console.NewScheduleBoardCommand(),
+		console.NewScheduleDigestCommand(),
+		console.NewNotificationsDeliverCommand(),
 		console.NewBotPollCommand(),
+		console.NewBotServeCommand(),
+		console.NewBotWebhookCommand(),
 		console.NewMigrateCommand(),
+		console.NewGamesSearchCommand(),
+		console.NewTemplatesPreviewCommand(),
 	}
 }
 
+// runCommand runs the command with context cancelled on SIGINT or SIGTERM
 func runCommand(commands *Commands, arg string) {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
 	found := false
 	for _, cmd := range *commands {
 		if arg == cmd.Name() {
 			slog.Info("command found", "command", cmd.Name())
 			found = true
-			if err := cmd.Run(); err != nil {
+			if err := cmd.Run(ctx); err != nil {
 				slog.Error(err.Error())
 				os.Exit(1)
 			}
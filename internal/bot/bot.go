@@ -1,18 +1,27 @@
 package bot
 
 import (
+	"errors"
+	"fmt"
 	"log/slog"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/kettari/location-bot/internal/config"
 	"github.com/kettari/location-bot/internal/entity"
+	"github.com/kettari/location-bot/internal/i18n"
 	tele "gopkg.in/telebot.v4"
 )
 
+// clearedBoardText replaces the board message which cannot be deleted, Telegram does not allow empty messages
+const clearedBoardText = "…"
+
 type Bot struct {
 	bot         *tele.Bot
 	destination []Recipient
+	queue       *queue
 }
 
 type Recipient struct {
@@ -20,10 +29,49 @@ type Recipient struct {
 	ThreadID int
 }
 
+// String returns the recipient as "chat_id,thread_id"
+func (r Recipient) String() string {
+	return fmt.Sprintf("%d,%d", r.User.ID, r.ThreadID)
+}
+
 // CreateBot returns [MessageDispatcher] object to send notifications
 //   - token is the Telegram bot token
 //   - recipients is a string "chat_id1,thread_id1;chat_id2,thread_id2"
-func CreateBot(token, recipients string) (entity.MessageDispatcher, error) {
+//   - limiter is shared with other bots of the process, nil means the bot has its own one
+func CreateBot(token, recipients string, limiter *Limiter) (entity.MessageDispatcher, error) {
+	pref := tele.Settings{
+		Token: token,
+	}
+	b, err := tele.NewBot(pref)
+	if err != nil {
+		slog.Error("unable to create bot processor object", "error", err)
+		return nil, err
+	}
+	return newBot(b, prepareDestination(recipients), limiter), nil
+}
+
+// CreateAnnouncementBot returns [AnnouncementDispatcher] object to post announcements
+// to the recipients and edit them later
+//   - token is the Telegram bot token
+//   - recipients is a string "chat_id1,thread_id1;chat_id2,thread_id2"
+//   - limiter is shared with other bots of the process, nil means the bot has its own one
+func CreateAnnouncementBot(token, recipients string, limiter *Limiter) (entity.AnnouncementDispatcher, error) {
+	pref := tele.Settings{
+		Token: token,
+	}
+	b, err := tele.NewBot(pref)
+	if err != nil {
+		slog.Error("unable to create bot processor object", "error", err)
+		return nil, err
+	}
+	return newBot(b, prepareDestination(recipients), limiter), nil
+}
+
+// CreateBoardBot returns [BoardDispatcher] object to keep the schedule board in the recipients chats
+//   - token is the Telegram bot token
+//   - recipients is a string "chat_id1,thread_id1;chat_id2,thread_id2"
+//   - limiter is shared with other bots of the process, nil means the bot has its own one
+func CreateBoardBot(token, recipients string, limiter *Limiter) (entity.BoardDispatcher, error) {
 	pref := tele.Settings{
 		Token: token,
 	}
@@ -32,17 +80,37 @@ func CreateBot(token, recipients string) (entity.MessageDispatcher, error) {
 		slog.Error("unable to create bot processor object", "error", err)
 		return nil, err
 	}
-	return &Bot{
-		bot:         b,
-		destination: prepareDestination(recipients),
-	}, nil
+	return newBot(b, prepareDestination(recipients), limiter), nil
+}
+
+// CreateDirectBot returns [DirectMessageDispatcher] object to send private messages
+// to the chats chosen by the caller; limiter is shared with other bots of the process, nil means the bot has its own one
+func CreateDirectBot(token string, limiter *Limiter) (entity.DirectMessageDispatcher, error) {
+	pref := tele.Settings{
+		Token: token,
+	}
+	b, err := tele.NewBot(pref)
+	if err != nil {
+		slog.Error("unable to create bot processor object", "error", err)
+		return nil, err
+	}
+	return newBot(b, nil, limiter), nil
+}
+
+func newBot(b *tele.Bot, destination []Recipient, limiter *Limiter) *Bot {
+	if limiter == nil {
+		limiter = NewLimiter()
+	}
+	result := &Bot{bot: b, destination: destination}
+	result.queue = &queue{send: result.sendPart, limiter: limiter, sleep: time.Sleep}
+	return result
 }
 
 // CreateBotFromConfig returns [MessageDispatcher] using configuration from environment
 // This is a convenience wrapper for backwards compatibility
 func CreateBotFromConfig(recipients string) (entity.MessageDispatcher, error) {
 	conf := config.GetConfig()
-	return CreateBot(conf.BotToken, recipients)
+	return CreateBot(conf.BotToken, recipients, nil)
 }
 
 // prepareDestination parses configuration files and prepares array with [gopkg.in/telebot.v4.User]
@@ -59,8 +127,8 @@ func prepareDestination(recipients string) []Recipient {
 	return result
 }
 
-// Send notification to all prepared recipients
-func (b *Bot) Send(notification []string) (err error) {
+// Send notification to all prepared recipients; returns [DeliveryError] if some of them failed
+func (b *Bot) Send(notification []string) error {
 	/* conf := config.GetConfig()
 	if conf.DryRun {
 		slog.Info("DRY RUN MODE: skipping Telegram message sending")
@@ -72,19 +140,218 @@ func (b *Bot) Send(notification []string) (err error) {
 		return nil
 	} */
 
+	return deliveryError(b.Deliver(notification))
+}
+
+// Deliver sends notification to all prepared recipients within Telegram rate limits
+// and returns delivery result per recipient; failed recipient does not block the others
+func (b *Bot) Deliver(notification []string) []DeliveryResult {
+	results := b.queue.deliver(b.destination, notification)
+	for _, result := range results {
+		if result.Err == nil {
+			slog.Debug("notification sent", "chat_id", result.Recipient.User.ID, "thread_id", result.Recipient.ThreadID, "parts_count", result.Sent)
+		}
+	}
+	return results
+}
+
+// SendExcept sends notification to prepared recipients except those listed in delivered as "chat_id,thread_id"
+// and returns recipients the notification was delivered to, even if some recipients failed
+func (b *Bot) SendExcept(notification []string, delivered []string) ([]string, error) {
+	var recipients []Recipient
 	for _, dest := range b.destination {
-		for _, txt := range notification {
-			if _, err = b.bot.Send(&dest.User, txt, &tele.SendOptions{
-				ParseMode: tele.ModeHTML, ThreadID: dest.ThreadID, DisableWebPagePreview: true}); err != nil {
-				slog.Error("failed to send notification", "chat_id", dest.User.ID, "thread_id", dest.ThreadID, "notification", txt, "error", err)
-				return err
+		if !slices.Contains(delivered, dest.String()) {
+			recipients = append(recipients, dest)
+		}
+	}
+
+	results := b.queue.deliver(recipients, notification)
+	var sent []string
+	for _, result := range results {
+		if result.Err == nil {
+			sent = append(sent, result.Recipient.String())
+		}
+	}
+	return sent, deliveryError(results)
+}
+
+// SendLocalized implements [entity.LocalizedDispatcher]: every recipient gets the message in the locale of its chat
+func (b *Bot) SendLocalized(notification entity.Localized) error {
+	return b.SendPartsLocalized(func(locale i18n.Locale) []string {
+		return []string{notification(locale, nil)}
+	})
+}
+
+// SendPartsLocalized implements [entity.LocalizedPartsDispatcher]: every recipient gets the message in the locale of its chat
+func (b *Bot) SendPartsLocalized(notification entity.LocalizedParts) error {
+	conf := config.GetConfig()
+	var locales []i18n.Locale
+	for _, dest := range b.destination {
+		if locale := conf.ChatLocale(dest.User.ID); !slices.Contains(locales, locale) {
+			locales = append(locales, locale)
+		}
+	}
+	var results []DeliveryResult
+	for _, locale := range locales {
+		var recipients []Recipient
+		for _, dest := range b.destination {
+			if conf.ChatLocale(dest.User.ID) == locale {
+				recipients = append(recipients, dest)
 			}
 		}
-		slog.Debug("notification sent", "chat_id", dest.User.ID, "thread_id", dest.ThreadID, "parts_count", len(notification))
+		results = append(results, b.queue.deliver(recipients, notification(locale))...)
 	}
+	return deliveryError(results)
+}
+
+// SendTo sends notification to the single chat
+func (b *Bot) SendTo(chatID int64, notification []string) error {
+	results := b.queue.deliver([]Recipient{{User: tele.User{ID: chatID}}}, notification)
+	if err := results[0].Err; err != nil {
+		return err
+	}
+	slog.Debug("direct notification sent", "parts_count", len(notification))
 	return nil
 }
 
+// Announce sends notification to prepared recipients except those listed in posted
+// and returns announcements delivered successfully, even if some recipients failed
+func (b *Bot) Announce(notification []string, posted []entity.Announcement) ([]entity.Announcement, error) {
+	var recipients []Recipient
+	for _, dest := range b.destination {
+		if !slices.ContainsFunc(posted, func(announcement entity.Announcement) bool {
+			return announcement.ChatID == dest.User.ID && announcement.ThreadID == dest.ThreadID
+		}) {
+			recipients = append(recipients, dest)
+		}
+	}
+
+	results := b.queue.deliver(recipients, notification)
+	var announcements []entity.Announcement
+	for _, result := range results {
+		if len(result.MessageIDs) > 0 {
+			announcements = append(announcements, entity.Announcement{
+				ChatID:    result.Recipient.User.ID,
+				ThreadID:  result.Recipient.ThreadID,
+				MessageID: result.MessageIDs[0],
+			})
+		}
+	}
+	return announcements, deliveryError(results)
+}
+
+// EditAnnouncement replaces text of the posted announcement; unchanged text is not an error
+func (b *Bot) EditAnnouncement(announcement *entity.Announcement, text string) error {
+	return b.editMessage(announcement.ChatID, announcement.MessageID, text)
+}
+
+// UpdateBoard implements [entity.BoardDispatcher] for every prepared recipient. Messages which cannot
+// be edited anymore, e.g. deleted by chat admins, are posted anew; the first message is pinned. Messages past
+// the parts, e.g. after the board size was reduced, are deleted or cleared if they are too old to delete
+func (b *Bot) UpdateBoard(posted []entity.BoardMessage, parts []string) ([]entity.BoardMessage, []entity.BoardMessage, error) {
+	var board, removed []entity.BoardMessage
+	var results []DeliveryResult
+	for _, dest := range b.destination {
+		result := DeliveryResult{Recipient: dest}
+		for position, text := range parts {
+			message := entity.BoardMessage{ChatID: dest.User.ID, ThreadID: dest.ThreadID, Position: position}
+			if k := slices.IndexFunc(posted, func(m entity.BoardMessage) bool {
+				return m.ChatID == message.ChatID && m.ThreadID == message.ThreadID && m.Position == position
+			}); k >= 0 {
+				message = posted[k]
+			}
+			if result.Err = b.updateBoardMessage(&message, text); result.Err != nil {
+				slog.Error("failed to update board message", "chat_id", dest.User.ID, "thread_id", dest.ThreadID, "position", position, "error", result.Err)
+				break
+			}
+			board = append(board, message)
+			result.Sent++
+			result.MessageIDs = append(result.MessageIDs, message.MessageID)
+		}
+		for _, message := range posted {
+			if message.ChatID != dest.User.ID || message.ThreadID != dest.ThreadID || message.Position < len(parts) {
+				continue
+			}
+			// Extra messages of the failed chat are kept for the next update
+			if result.Err == nil {
+				result.Err = b.removeBoardMessage(&message)
+			}
+			if result.Err != nil {
+				board = append(board, message)
+				continue
+			}
+			removed = append(removed, message)
+		}
+		results = append(results, result)
+	}
+	return board, removed, deliveryError(results)
+}
+
+// removeBoardMessage deletes the board message; the message too old to delete is cleared instead,
+// the message which is gone already is not an error
+func (b *Bot) removeBoardMessage(message *entity.BoardMessage) error {
+	stored := tele.StoredMessage{MessageID: strconv.Itoa(message.MessageID), ChatID: message.ChatID}
+	err := b.queue.call(message.ChatID, func() error {
+		return b.bot.Delete(stored)
+	})
+	if err == nil || !IsPermanent(err) {
+		return err
+	}
+	slog.Warn("board message cannot be deleted, clearing it", "chat_id", message.ChatID, "message_id", message.MessageID, "error", err)
+	if err = b.editMessage(message.ChatID, message.MessageID, clearedBoardText); err != nil && !IsPermanent(err) {
+		return err
+	}
+	return nil
+}
+
+func (b *Bot) updateBoardMessage(message *entity.BoardMessage, text string) error {
+	if message.MessageID != 0 {
+		err := b.editMessage(message.ChatID, message.MessageID, text)
+		if err == nil || !IsPermanent(err) {
+			return err
+		}
+		slog.Warn("board message cannot be edited, posting new one", "chat_id", message.ChatID, "message_id", message.MessageID, "error", err)
+	}
+
+	recipient := Recipient{User: tele.User{ID: message.ChatID}, ThreadID: message.ThreadID}
+	result := b.queue.deliver([]Recipient{recipient}, []string{text})[0]
+	if result.Err != nil {
+		return result.Err
+	}
+	message.MessageID = result.MessageIDs[0]
+	if message.Position == 0 {
+		pinned := tele.StoredMessage{MessageID: strconv.Itoa(message.MessageID), ChatID: message.ChatID}
+		if err := b.queue.call(message.ChatID, func() error {
+			return b.bot.Pin(pinned, tele.Silent)
+		}); err != nil {
+			slog.Warn("failed to pin board message, does the bot have the right to pin?", "chat_id", message.ChatID, "error", err)
+		}
+	}
+	return nil
+}
+
+// editMessage replaces text of the message sent by the bot; unchanged text is not an error
+func (b *Bot) editMessage(chatID int64, messageID int, text string) error {
+	message := tele.StoredMessage{MessageID: strconv.Itoa(messageID), ChatID: chatID}
+	err := b.queue.call(chatID, func() error {
+		_, err := b.bot.Edit(message, text, &tele.SendOptions{ParseMode: tele.ModeHTML, DisableWebPagePreview: true})
+		return err
+	})
+	if errors.Is(err, tele.ErrSameMessageContent) || errors.Is(err, tele.ErrMessageNotModified) {
+		return nil
+	}
+	return err
+}
+
+func (b *Bot) sendPart(recipient Recipient, text string) (int, error) {
+	message, err := b.bot.Send(&recipient.User, text, &tele.SendOptions{
+		ParseMode: tele.ModeHTML, ThreadID: recipient.ThreadID, DisableWebPagePreview: true})
+	if err != nil {
+		return 0, err
+	}
+	return message.ID, nil
+}
+
 /* func min(a, b int) int {
 	if a < b {
 		return a
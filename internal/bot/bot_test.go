@@ -1,9 +1,19 @@
 package bot
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"slices"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/kettari/location-bot/internal/entity"
+	tele "gopkg.in/telebot.v4"
 )
 
 func TestCreateBot(t *testing.T) {
@@ -13,7 +23,7 @@ func TestCreateBot(t *testing.T) {
 
 	// Note: This will fail at runtime because the token is invalid,
 	// but it tests that the signature is correct
-	_, err := CreateBot(token, recipients)
+	_, err := CreateBot(token, recipients, nil)
 
 	// We expect an error because the token is invalid, but the function signature is correct
 	if err == nil {
@@ -30,10 +40,100 @@ func TestCreateBot_Signature(t *testing.T) {
 	// so we just check that the function signature is correct
 	var dispatcher entity.MessageDispatcher
 
-	_, err := CreateBot(token, recipients)
+	_, err := CreateBot(token, recipients, nil)
 	// We ignore the error - we're just testing the signature
 
 	// This should compile - the function returns the right type
 	_ = dispatcher
 	_ = err
 }
+
+func TestBot_SendExcept(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	var sentTo []int64
+	failing := true
+	b := &Bot{destination: []Recipient{{User: tele.User{ID: -1}}, {User: tele.User{ID: -2}, ThreadID: 5}, {User: tele.User{ID: -3}}}}
+	b.queue = newTestQueue(clock, func(recipient Recipient, text string) (int, error) {
+		if recipient.User.ID == -3 && failing {
+			return 0, errors.New("telegram: internal server error (500)")
+		}
+		sentTo = append(sentTo, recipient.User.ID)
+		return len(sentTo), nil
+	})
+
+	delivered, err := b.SendExcept([]string{"text"}, []string{"-1,0"})
+	if err == nil {
+		t.Error("SendExcept() error = nil, want delivery error of the failed chat")
+	}
+	if want := []string{"-2,5"}; !slices.Equal(delivered, want) {
+		t.Errorf("delivered = %v, want %v", delivered, want)
+	}
+
+	// Retry goes only to the chat which failed
+	failing = false
+	delivered, err = b.SendExcept([]string{"text"}, []string{"-1,0", "-2,5"})
+	if err != nil || !slices.Equal(delivered, []string{"-3,0"}) {
+		t.Errorf("retry SendExcept() = %v, %v, want -3,0 delivered", delivered, err)
+	}
+	if want := []int64{-2, -3}; !slices.Equal(sentTo, want) {
+		t.Errorf("sent to %v, want %v", sentTo, want)
+	}
+}
+
+func TestBot_UpdateBoard_RemovesExtraMessages(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&params)
+		method := path.Base(r.URL.Path)
+		mu.Lock()
+		calls = append(calls, fmt.Sprintf("%s %v", method, params["message_id"]))
+		mu.Unlock()
+		switch {
+		case method == "deleteMessage" && params["message_id"] == "13":
+			// Too old to delete
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"ok":false,"error_code":400,"description":"Bad Request: message can't be deleted"}`)
+		case method == "editMessageText":
+			fmt.Fprint(w, `{"ok":true,"result":{"message_id":1,"chat":{"id":-100}}}`)
+		default:
+			fmt.Fprint(w, `{"ok":true,"result":true}`)
+		}
+	}))
+	defer server.Close()
+
+	tb, err := tele.NewBot(tele.Settings{URL: server.URL, Token: "test_token", Offline: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := newBot(tb, []Recipient{{User: tele.User{ID: -100}}}, nil)
+	b.queue.sleep = func(time.Duration) {}
+
+	posted := []entity.BoardMessage{
+		{ChatID: -100, Position: 0, MessageID: 10},
+		{ChatID: -100, Position: 1, MessageID: 11},
+		{ChatID: -100, Position: 2, MessageID: 12},
+		{ChatID: -100, Position: 3, MessageID: 13},
+		// Board of another chat is not touched
+		{ChatID: -200, Position: 2, MessageID: 20},
+	}
+	board, removed, err := b.UpdateBoard(posted, []string{"first", "second"})
+	if err != nil {
+		t.Fatalf("UpdateBoard() error = %v", err)
+	}
+	if len(board) != 2 {
+		t.Errorf("board = %+v, want 2 messages", board)
+	}
+	var removedIDs []int
+	for _, message := range removed {
+		removedIDs = append(removedIDs, message.MessageID)
+	}
+	if want := []int{12, 13}; !slices.Equal(removedIDs, want) {
+		t.Errorf("removed = %v, want %v", removedIDs, want)
+	}
+	want := []string{"editMessageText 10", "editMessageText 11", "deleteMessage 12", "deleteMessage 13", "editMessageText 13"}
+	if !slices.Equal(calls, want) {
+		t.Errorf("Telegram calls = %q, want %q", calls, want)
+	}
+}
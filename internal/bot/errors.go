@@ -0,0 +1,48 @@
+package bot
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// IsPermanent reports whether sending the same message again cannot succeed,
+// e.g. the user blocked the bot, the chat does not exist or the message is malformed
+func IsPermanent(err error) bool {
+	// Undelivered notification is permanently failed only if all failed recipients are
+	if deliveryErr, ok := isDeliveryError(err); ok {
+		for _, result := range deliveryErr.Failed() {
+			if !IsPermanent(result.Err) {
+				return false
+			}
+		}
+		return true
+	}
+	var groupErr tele.GroupError
+	if errors.As(err, &groupErr) {
+		return true
+	}
+	var teleErr *tele.Error
+	if errors.As(err, &teleErr) {
+		return teleErr.Code == http.StatusBadRequest || teleErr.Code == http.StatusForbidden
+	}
+	return false
+}
+
+// RetryAfter returns delay requested by Telegram flood control, zero if the error is not a flood error
+func RetryAfter(err error) time.Duration {
+	if deliveryErr, ok := isDeliveryError(err); ok {
+		var retryAfter time.Duration
+		for _, result := range deliveryErr.Failed() {
+			retryAfter = max(retryAfter, RetryAfter(result.Err))
+		}
+		return retryAfter
+	}
+	var floodErr tele.FloodError
+	if errors.As(err, &floodErr) {
+		return time.Duration(floodErr.RetryAfter) * time.Second
+	}
+	return 0
+}
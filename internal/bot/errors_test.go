@@ -0,0 +1,41 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+func TestIsPermanent(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "blocked by user", err: tele.ErrBlockedByUser, want: true},
+		{name: "chat not found", err: tele.ErrChatNotFound, want: true},
+		{name: "wrapped bad request", err: fmt.Errorf("send: %w", tele.NewError(400, "Bad Request: can't parse entities")), want: true},
+		{name: "flood", err: tele.FloodError{RetryAfter: 5}, want: false},
+		{name: "internal", err: tele.ErrInternal, want: false},
+		{name: "network", err: errors.New("connection reset by peer"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsPermanent(tt.err); got != tt.want {
+				t.Errorf("IsPermanent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if got := RetryAfter(tele.FloodError{RetryAfter: 7}); got != 7*time.Second {
+		t.Errorf("RetryAfter() = %v, want 7s", got)
+	}
+	if got := RetryAfter(tele.ErrInternal); got != 0 {
+		t.Errorf("RetryAfter() = %v, want 0", got)
+	}
+}
@@ -0,0 +1,80 @@
+package bot
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+const (
+	pollerMinBackoff = 1 * time.Second
+	pollerMaxBackoff = 1 * time.Minute
+)
+
+// ResilientPoller is a long poller which backs off and restarts polling after network failures.
+// [gopkg.in/telebot.v4.LongPoller] retries failed requests immediately in a tight loop
+type ResilientPoller struct {
+	Timeout      time.Duration
+	LastUpdateID int
+}
+
+// NewResilientPoller returns poller with the given long polling timeout
+func NewResilientPoller(timeout time.Duration) *ResilientPoller {
+	return &ResilientPoller{Timeout: timeout}
+}
+
+// Poll implements [gopkg.in/telebot.v4.Poller]
+func (p *ResilientPoller) Poll(b *tele.Bot, dest chan tele.Update, stop chan struct{}) {
+	backoff := pollerMinBackoff
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		updates, err := p.getUpdates(b)
+		if err != nil {
+			slog.Warn("failed to poll updates, restarting poller", "error", err, "retry_in", backoff)
+			select {
+			case <-stop:
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, pollerMaxBackoff)
+			continue
+		}
+		backoff = pollerMinBackoff
+
+		for _, update := range updates {
+			p.LastUpdateID = update.ID
+			dest <- update
+		}
+	}
+}
+
+func (p *ResilientPoller) getUpdates(b *tele.Bot) ([]tele.Update, error) {
+	allowed, err := json.Marshal(tele.AllowedUpdates)
+	if err != nil {
+		return nil, err
+	}
+	data, err := b.Raw("getUpdates", map[string]string{
+		"offset":          strconv.Itoa(p.LastUpdateID + 1),
+		"timeout":         strconv.Itoa(int(p.Timeout / time.Second)),
+		"allowed_updates": string(allowed),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Result []tele.Update
+	}
+	if err = json.Unmarshal(data, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Result, nil
+}
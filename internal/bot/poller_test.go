@@ -0,0 +1,61 @@
+package bot
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+func TestResilientPoller_RecoversAfterFailure(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch requests.Add(1) {
+		case 1:
+			// Simulate network failure of the first poll
+			http.Error(w, "Bad Gateway", http.StatusBadGateway)
+		case 2:
+			fmt.Fprint(w, `{"ok":true,"result":[{"update_id":42,"message":{"message_id":1,"text":"/help","chat":{"id":1,"type":"private"}}}]}`)
+		default:
+			fmt.Fprint(w, `{"ok":true,"result":[]}`)
+		}
+	}))
+	defer server.Close()
+
+	b, err := tele.NewBot(tele.Settings{Token: "test_token", URL: server.URL, Offline: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	poller := NewResilientPoller(0)
+	updates := make(chan tele.Update, 1)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		poller.Poll(b, updates, stop)
+		close(done)
+	}()
+
+	select {
+	case update := <-updates:
+		if update.ID != 42 {
+			t.Errorf("Poll() update ID = %d, want 42", update.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Poll() did not recover after failure")
+	}
+
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Poll() did not stop")
+	}
+	if poller.LastUpdateID != 42 {
+		t.Errorf("Poll() LastUpdateID = %d, want 42", poller.LastUpdateID)
+	}
+}
@@ -0,0 +1,197 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Telegram allows about 30 messages per second overall, one message per second
+	// to the same private chat and 20 messages per minute to the same group
+	globalInterval      = time.Second / 30
+	privateChatInterval = time.Second
+	groupChatInterval   = 3 * time.Second
+	// maxFloodRetries is the number of attempts to resend the message after 429 flood error
+	maxFloodRetries = 3
+)
+
+// DeliveryResult is the outcome of the notification for the single recipient
+type DeliveryResult struct {
+	Recipient Recipient
+	// Sent is the number of delivered notification parts
+	Sent int
+	// MessageIDs are Telegram IDs of delivered parts
+	MessageIDs []int
+	Err        error
+}
+
+// DeliveryError is returned when the notification was not delivered to some recipients
+type DeliveryError struct {
+	Results []DeliveryResult
+}
+
+func (e *DeliveryError) Error() string {
+	var failures []string
+	for _, result := range e.Failed() {
+		failures = append(failures, fmt.Sprintf("chat %d thread %d: %s",
+			result.Recipient.User.ID, result.Recipient.ThreadID, result.Err))
+	}
+	return fmt.Sprintf("notification not delivered to %d of %d recipients: %s",
+		len(failures), len(e.Results), strings.Join(failures, "; "))
+}
+
+// Unwrap returns errors of failed recipients
+func (e *DeliveryError) Unwrap() []error {
+	var errs []error
+	for _, result := range e.Failed() {
+		errs = append(errs, result.Err)
+	}
+	return errs
+}
+
+// Failed returns results of recipients the notification was not delivered to
+func (e *DeliveryError) Failed() []DeliveryResult {
+	var failed []DeliveryResult
+	for _, result := range e.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// deliveryError returns [DeliveryError] if any recipient failed, nil otherwise
+func deliveryError(results []DeliveryResult) error {
+	for _, result := range results {
+		if result.Err != nil {
+			return &DeliveryError{Results: results}
+		}
+	}
+	return nil
+}
+
+// Limiter spaces out messages to stay within Telegram limits. The limits apply to the bot token, not to
+// the [Bot] object, so bots created with the same token in one process must share the limiter
+type Limiter struct {
+	mu    sync.Mutex
+	now   func() time.Time
+	next  time.Time
+	chats map[int64]time.Time
+}
+
+// NewLimiter returns limiter to share between bots of one process
+func NewLimiter() *Limiter {
+	return newLimiter(time.Now)
+}
+
+func newLimiter(now func() time.Time) *Limiter {
+	return &Limiter{now: now, chats: make(map[int64]time.Time)}
+}
+
+// reserve books the slot for the message to the chat and returns how long to wait for it
+func (l *Limiter) reserve(chatID int64) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	at := now
+	if l.next.After(at) {
+		at = l.next
+	}
+	if chatNext := l.chats[chatID]; chatNext.After(at) {
+		at = chatNext
+	}
+	l.next = at.Add(globalInterval)
+	l.chats[chatID] = at.Add(chatInterval(chatID))
+
+	// Forget chats which are long idle
+	for id, chatNext := range l.chats {
+		if chatNext.Before(now) {
+			delete(l.chats, id)
+		}
+	}
+
+	return at.Sub(now)
+}
+
+// pause postpones messages to the chat and all other messages after the flood error
+func (l *Limiter) pause(chatID int64, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	until := l.now().Add(retryAfter)
+	if until.After(l.next) {
+		l.next = until
+	}
+	if until.After(l.chats[chatID]) {
+		l.chats[chatID] = until
+	}
+}
+
+// chatInterval returns minimal interval between messages to the chat; groups and channels have negative IDs
+func chatInterval(chatID int64) time.Duration {
+	if chatID < 0 {
+		return groupChatInterval
+	}
+	return privateChatInterval
+}
+
+// queue sends notification parts to recipients one by one within rate limits
+type queue struct {
+	send    func(recipient Recipient, text string) (int, error)
+	limiter *Limiter
+	sleep   func(time.Duration)
+}
+
+// deliver sends all parts to every recipient in order. Flood errors are retried after retry_after,
+// other errors stop delivery to the failed recipient only
+func (q *queue) deliver(recipients []Recipient, notification []string) []DeliveryResult {
+	results := make([]DeliveryResult, 0, len(recipients))
+	for _, recipient := range recipients {
+		result := DeliveryResult{Recipient: recipient}
+		for _, text := range notification {
+			var messageID int
+			result.Err = q.call(recipient.User.ID, func() (err error) {
+				messageID, err = q.send(recipient, text)
+				return err
+			})
+			if result.Err != nil {
+				slog.Error("failed to send notification",
+					"chat_id", recipient.User.ID, "thread_id", recipient.ThreadID, "error", result.Err)
+				break
+			}
+			result.Sent++
+			result.MessageIDs = append(result.MessageIDs, messageID)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// call runs the Telegram request to the chat within rate limits, retrying it after flood errors
+func (q *queue) call(chatID int64, request func() error) error {
+	for attempt := 0; ; attempt++ {
+		if wait := q.limiter.reserve(chatID); wait > 0 {
+			q.sleep(wait)
+		}
+		err := request()
+		retryAfter := RetryAfter(err)
+		if retryAfter == 0 || attempt >= maxFloodRetries {
+			return err
+		}
+		slog.Warn("telegram flood control, retrying",
+			"chat_id", chatID, "retry_after", retryAfter, "attempt", attempt+1)
+		q.limiter.pause(chatID, retryAfter)
+	}
+}
+
+// isDeliveryError reports whether err is [DeliveryError] and returns it
+func isDeliveryError(err error) (*DeliveryError, bool) {
+	var deliveryErr *DeliveryError
+	ok := errors.As(err, &deliveryErr)
+	return deliveryErr, ok
+}
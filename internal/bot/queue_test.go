@@ -0,0 +1,165 @@
+package bot
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// fakeClock is advanced by sleeping instead of waiting
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+type sentMessage struct {
+	chatID int64
+	text   string
+	at     time.Time
+}
+
+func newTestQueue(clock *fakeClock, send func(recipient Recipient, text string) (int, error)) *queue {
+	return &queue{send: send, limiter: newLimiter(clock.Now), sleep: clock.Sleep}
+}
+
+func TestQueue_RateLimits(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	start := clock.now
+	var sent []sentMessage
+	q := newTestQueue(clock, func(recipient Recipient, text string) (int, error) {
+		sent = append(sent, sentMessage{chatID: recipient.User.ID, text: text, at: clock.now})
+		return len(sent), nil
+	})
+
+	recipients := []Recipient{{User: tele.User{ID: -100}}, {User: tele.User{ID: 42}}}
+	results := q.deliver(recipients, []string{"part 1", "part 2"})
+
+	for _, result := range results {
+		if result.Err != nil || result.Sent != 2 || len(result.MessageIDs) != 2 {
+			t.Errorf("result for chat %d = %+v, want 2 parts sent", result.Recipient.User.ID, result)
+		}
+	}
+	if ids := results[1].MessageIDs; ids[0] != 3 || ids[1] != 4 {
+		t.Errorf("message IDs = %v, want [3 4]", ids)
+	}
+	if len(sent) != 4 {
+		t.Fatalf("sent %d messages, want 4", len(sent))
+	}
+	// Group chat gets the second part 3 seconds later
+	if got := sent[1].at.Sub(sent[0].at); got != groupChatInterval {
+		t.Errorf("group chat interval = %v, want %v", got, groupChatInterval)
+	}
+	// Private chat messages are spaced by a second
+	if got := sent[3].at.Sub(sent[2].at); got != privateChatInterval {
+		t.Errorf("private chat interval = %v, want %v", got, privateChatInterval)
+	}
+	// The first message to another chat waits only for the global limit
+	if got := sent[2].at.Sub(start); got != groupChatInterval+globalInterval {
+		t.Errorf("first private message at %v, want %v", got, groupChatInterval+globalInterval)
+	}
+}
+
+func TestQueue_FloodRetryAfter(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	var sent []sentMessage
+	floods := 1
+	q := newTestQueue(clock, func(recipient Recipient, text string) (int, error) {
+		if floods > 0 {
+			floods--
+			return 0, tele.FloodError{RetryAfter: 10}
+		}
+		sent = append(sent, sentMessage{chatID: recipient.User.ID, text: text, at: clock.now})
+		return len(sent), nil
+	})
+
+	start := clock.now
+	results := q.deliver([]Recipient{{User: tele.User{ID: 42}}}, []string{"hello"})
+	if results[0].Err != nil {
+		t.Fatalf("deliver() error = %v", results[0].Err)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(sent))
+	}
+	if got := sent[0].at.Sub(start); got < 10*time.Second {
+		t.Errorf("message resent after %v, want at least retry_after 10s", got)
+	}
+}
+
+func TestQueue_FloodRetriesExhausted(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	calls := 0
+	q := newTestQueue(clock, func(recipient Recipient, text string) (int, error) {
+		calls++
+		return 0, tele.FloodError{RetryAfter: 1}
+	})
+
+	results := q.deliver([]Recipient{{User: tele.User{ID: 42}}}, []string{"hello"})
+	if RetryAfter(results[0].Err) != time.Second {
+		t.Errorf("deliver() error = %v, want flood error", results[0].Err)
+	}
+	if calls != maxFloodRetries+1 {
+		t.Errorf("send called %d times, want %d", calls, maxFloodRetries+1)
+	}
+}
+
+func TestQueue_FailedRecipientDoesNotBlockOthers(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	var sent []sentMessage
+	q := newTestQueue(clock, func(recipient Recipient, text string) (int, error) {
+		if recipient.User.ID == 1 {
+			return 0, tele.ErrBlockedByUser
+		}
+		sent = append(sent, sentMessage{chatID: recipient.User.ID, text: text, at: clock.now})
+		return len(sent), nil
+	})
+
+	recipients := []Recipient{{User: tele.User{ID: 1}}, {User: tele.User{ID: 2}}, {User: tele.User{ID: 3}}}
+	results := q.deliver(recipients, []string{"part 1", "part 2"})
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	if !errors.Is(results[0].Err, tele.ErrBlockedByUser) || results[0].Sent != 0 {
+		t.Errorf("result of blocked recipient = %+v", results[0])
+	}
+	if results[1].Err != nil || results[2].Err != nil || len(sent) != 4 {
+		t.Errorf("other recipients were not served: %+v, sent %d", results[1:], len(sent))
+	}
+
+	err := deliveryError(results)
+	var deliveryErr *DeliveryError
+	if !errors.As(err, &deliveryErr) || len(deliveryErr.Failed()) != 1 {
+		t.Fatalf("deliveryError() = %v, want one failed recipient", err)
+	}
+	if !errors.Is(err, tele.ErrBlockedByUser) {
+		t.Error("DeliveryError does not unwrap recipient error")
+	}
+	if !IsPermanent(err) {
+		t.Error("IsPermanent() = false for notification failed only for blocked recipient")
+	}
+}
+
+func TestDeliveryError_MixedFailures(t *testing.T) {
+	err := deliveryError([]DeliveryResult{
+		{Recipient: Recipient{User: tele.User{ID: 1}}, Err: tele.ErrBlockedByUser},
+		{Recipient: Recipient{User: tele.User{ID: 2}}, Err: tele.FloodError{RetryAfter: 30}},
+		{Recipient: Recipient{User: tele.User{ID: 3}}, Sent: 1},
+	})
+	if IsPermanent(err) {
+		t.Error("IsPermanent() = true while one recipient failed temporarily")
+	}
+	if got := RetryAfter(err); got != 30*time.Second {
+		t.Errorf("RetryAfter() = %v, want 30s", got)
+	}
+	if deliveryError([]DeliveryResult{{Sent: 1}}) != nil {
+		t.Error("deliveryError() is not nil when all recipients succeeded")
+	}
+}
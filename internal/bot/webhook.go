@@ -0,0 +1,147 @@
+package bot
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// WebhookPoller receives updates pushed by Telegram to the HTTP endpoint.
+// Unlike [gopkg.in/telebot.v4.Webhook] it rejects requests with invalid secret token,
+// reports failures through Failed and shuts the server down cleanly on bot stop
+type WebhookPoller struct {
+	// Listen is the local address, e.g. ":8080"; empty means the caller serves the poller itself
+	Listen string
+	// PublicURL is registered in Telegram with setWebhook; empty skips the registration
+	PublicURL string
+	// SecretToken is required: without it every request is rejected
+	SecretToken string
+	// TLSCert and TLSKey enable HTTPS listener; the certificate is uploaded to Telegram, so it may be self-signed
+	TLSCert string
+	TLSKey  string
+
+	mu     sync.RWMutex
+	dest   chan tele.Update
+	failed chan error
+}
+
+// Failed returns channel receiving the error which stopped the poller from receiving updates,
+// e.g. failed webhook registration; the bot must be stopped then
+func (p *WebhookPoller) Failed() <-chan error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.failed == nil {
+		p.failed = make(chan error, 1)
+	}
+	return p.failed
+}
+
+// fail reports the error and waits for the bot to stop
+func (p *WebhookPoller) fail(err error, stop chan struct{}) {
+	p.Failed()
+	select {
+	case p.failed <- err:
+	default:
+	}
+	<-stop
+}
+
+// Poll implements [gopkg.in/telebot.v4.Poller]
+func (p *WebhookPoller) Poll(b *tele.Bot, dest chan tele.Update, stop chan struct{}) {
+	if len(p.PublicURL) > 0 {
+		if err := b.SetWebhook(p.webhook()); err != nil {
+			slog.Error("failed to register webhook", "error", err)
+			p.fail(fmt.Errorf("failed to register webhook: %w", err), stop)
+			return
+		}
+		slog.Info("webhook registered", "url", p.PublicURL)
+	}
+
+	p.mu.Lock()
+	p.dest = dest
+	p.mu.Unlock()
+
+	if len(p.Listen) == 0 {
+		<-stop
+		return
+	}
+
+	server := &http.Server{
+		Addr:              p.Listen,
+		Handler:           p,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-stop
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			slog.Error("failed to shutdown webhook server", "error", err)
+		}
+	}()
+
+	slog.Info("webhook server listening", "address", p.Listen, "tls", len(p.TLSCert) > 0)
+	var err error
+	if len(p.TLSCert) > 0 {
+		err = server.ListenAndServeTLS(p.TLSCert, p.TLSKey)
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("webhook server failed", "error", err)
+		p.fail(fmt.Errorf("webhook server failed: %w", err), stop)
+	}
+}
+
+// ServeHTTP accepts single update JSON from Telegram
+func (p *WebhookPoller) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if len(p.SecretToken) == 0 ||
+		subtle.ConstantTimeCompare([]byte(r.Header.Get(secretTokenHeader)), []byte(p.SecretToken)) != 1 {
+		slog.Warn("webhook request with invalid secret token rejected", "remote_addr", r.RemoteAddr)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	p.mu.RLock()
+	dest := p.dest
+	p.mu.RUnlock()
+	if dest == nil {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	var update tele.Update
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&update); err != nil {
+		slog.Warn("cannot decode webhook update", "error", err)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	dest <- update
+}
+
+// webhook returns telebot webhook settings used for the setWebhook call
+func (p *WebhookPoller) webhook() *tele.Webhook {
+	webhook := &tele.Webhook{
+		SecretToken:    p.SecretToken,
+		AllowedUpdates: tele.AllowedUpdates,
+		Endpoint:       &tele.WebhookEndpoint{PublicURL: p.PublicURL},
+	}
+	if len(p.TLSCert) > 0 {
+		webhook.Endpoint.Cert = p.TLSCert
+	}
+	return webhook
+}
@@ -0,0 +1,135 @@
+package bot
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+func TestWebhookPoller_EndToEnd(t *testing.T) {
+	update, err := os.ReadFile("testdata/update_help.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	poller := &WebhookPoller{SecretToken: "test-secret"}
+	b, err := tele.NewBot(tele.Settings{Token: "test_token", Offline: true, Poller: poller})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handled := make(chan string, 1)
+	b.Handle("/help", func(c tele.Context) error {
+		handled <- c.Text()
+		return nil
+	})
+	go b.Start()
+	defer b.Stop()
+
+	server := httptest.NewServer(poller)
+	defer server.Close()
+
+	post := func(t *testing.T, secret string, body []byte) int {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, server.URL, bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(secret) > 0 {
+			req.Header.Set(secretTokenHeader, secret)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// Poller becomes ready once the bot is started
+	deadline := time.Now().Add(5 * time.Second)
+	for post(t, "test-secret", []byte("{}")) == http.StatusServiceUnavailable {
+		if time.Now().After(deadline) {
+			t.Fatal("webhook poller did not become ready")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	tests := []struct {
+		name   string
+		secret string
+		body   []byte
+		want   int
+	}{
+		{name: "missing secret", secret: "", body: update, want: http.StatusUnauthorized},
+		{name: "wrong secret", secret: "wrong-secret", body: update, want: http.StatusUnauthorized},
+		{name: "malformed update", secret: "test-secret", body: []byte("{"), want: http.StatusBadRequest},
+		{name: "recorded update", secret: "test-secret", body: update, want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := post(t, tt.secret, tt.body); got != tt.want {
+				t.Errorf("POST status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	select {
+	case text := <-handled:
+		if text != "/help" {
+			t.Errorf("handler got text %q, want /help", text)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("recorded update was not handled")
+	}
+	select {
+	case text := <-handled:
+		t.Errorf("update with invalid secret was handled: %q", text)
+	default:
+	}
+}
+
+func TestWebhookPoller_MethodNotAllowed(t *testing.T) {
+	poller := &WebhookPoller{}
+	recorder := httptest.NewRecorder()
+	poller.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET status = %d, want %d", recorder.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestWebhookPoller_NoSecret(t *testing.T) {
+	poller := &WebhookPoller{}
+	recorder := httptest.NewRecorder()
+	poller.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte("{}"))))
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("POST status = %d, want %d without configured secret", recorder.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestWebhookPoller_ServerFailed(t *testing.T) {
+	// The address is taken, so the webhook server cannot listen
+	busy := httptest.NewServer(http.NotFoundHandler())
+	defer busy.Close()
+
+	poller := &WebhookPoller{Listen: busy.Listener.Addr().String(), SecretToken: "test-secret"}
+	b, err := tele.NewBot(tele.Settings{Token: "test_token", Offline: true, Poller: poller})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go b.Start()
+	defer b.Stop()
+
+	select {
+	case err := <-poller.Failed():
+		if err == nil {
+			t.Error("Failed() got nil error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("poller failure was not reported")
+	}
+}
@@ -1,9 +1,20 @@
 package config
 
 import (
+	"fmt"
 	"log/slog"
 	"os"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/kettari/location-bot/internal/entity"
+	"github.com/kettari/location-bot/internal/i18n"
+	"github.com/kettari/location-bot/internal/scraper"
+	"github.com/kettari/location-bot/internal/templates"
+	"github.com/kettari/location-bot/internal/transport"
 )
 
 type Config struct {
@@ -14,10 +25,44 @@ type Config struct {
 	OpenAIApiKey       string
 	DbConnectionString string
 	NotificationChatID string
+	FetchInterval      time.Duration
+	WebhookURL         string
+	WebhookListen      string
+	WebhookSecret      string
+	WebhookTLSCert     string
+	WebhookTLSKey      string
+	Board              bool
+	BoardMessages      int
+	Transports         string
+	Routes             entity.Routes
+	TemplatesDir       string
+	Locale             i18n.Locale
+	ChatLocales        map[int64]i18n.Locale
+	// BulkReleaseRun and BulkReleasePage are numbers of new games in one fetch run or on one event page
+	// above which they are announced by one grouped message; zero disables the check
+	BulkReleaseRun  int
+	BulkReleasePage int
+	// AbsenceGuard limits cancellations of games absent from the fetch run
+	AbsenceGuard entity.AbsenceGuard
+	// AdminChatID is "chat_id,thread_id" of the chat alerted about anomalous fetch runs; empty means logs only
+	AdminChatID string
+	// FetchRetry repeats scraper requests failed with transient errors
+	FetchRetry scraper.RetryPolicy
+	// FetchTimeout limits the whole schedule:fetch run; zero means no limit
+	FetchTimeout time.Duration
+	// FetchCacheDir keeps validators and hashes of event pages to skip unchanged ones; empty disables the cache
+	FetchCacheDir string
+	// FetchRPS and FetchBurst limit scraper requests to one host, FetchContact is put to User-Agent
+	FetchRPS     float64
+	FetchBurst   int
+	FetchContact string
 }
 
 var config *Config
 
+// webhookSecretPattern is the set of characters Telegram allows in the webhook secret token
+var webhookSecretPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
 func GetConfig() *Config {
 	if config != nil {
 		return config
@@ -74,6 +119,146 @@ func GetConfig() *Config {
 		os.Exit(1)
 	}
 
+	// Interval of the in-process schedule fetch in bot:serve, disabled if not set
+	fetchInterval := os.Getenv("BOT_FETCH_INTERVAL")
+	if len(fetchInterval) > 0 {
+		interval, err := time.ParseDuration(fetchInterval)
+		if err != nil || interval < 0 {
+			slog.Error("invalid schedule fetch interval in the environment (BOT_FETCH_INTERVAL)", "value", fetchInterval)
+			os.Exit(1)
+		}
+		config.FetchInterval = interval
+	}
+
+	// Webhook mode of the bot (bot:webhook), URL and secret are checked by the command
+	config.WebhookURL = os.Getenv("BOT_WEBHOOK_URL")
+	config.WebhookListen = os.Getenv("BOT_WEBHOOK_LISTEN")
+	if len(config.WebhookListen) == 0 {
+		config.WebhookListen = ":8080"
+	}
+	config.WebhookSecret = os.Getenv("BOT_WEBHOOK_SECRET")
+	if len(config.WebhookSecret) > 0 && !webhookSecretPattern.MatchString(config.WebhookSecret) {
+		slog.Error("webhook secret must be 1-256 characters A-Z, a-z, 0-9, _ and - (BOT_WEBHOOK_SECRET)")
+		os.Exit(1)
+	}
+	config.WebhookTLSCert = os.Getenv("BOT_WEBHOOK_TLS_CERT")
+	config.WebhookTLSKey = os.Getenv("BOT_WEBHOOK_TLS_KEY")
+	if (len(config.WebhookTLSCert) > 0) != (len(config.WebhookTLSKey) > 0) {
+		slog.Error("webhook TLS requires both certificate and key (BOT_WEBHOOK_TLS_CERT, BOT_WEBHOOK_TLS_KEY)")
+		os.Exit(1)
+	}
+
+	// Schedule board in notification chats, updated after every fetch if enabled
+	board := os.Getenv("BOT_BOARD")
+	if strings.ToLower(board) == "true" || board == "1" {
+		config.Board = true
+	}
+	config.BoardMessages = 3
+	if boardMessages := os.Getenv("BOT_BOARD_MESSAGES"); len(boardMessages) > 0 {
+		size, err := strconv.Atoi(boardMessages)
+		if err != nil || size < 1 || size > 10 {
+			slog.Error("number of board messages must be from 1 to 10 (BOT_BOARD_MESSAGES)", "value", boardMessages)
+			os.Exit(1)
+		}
+		config.BoardMessages = size
+	}
+
+	// Additional transports of broadcast notifications, see [transport.Parse]
+	config.Transports = os.Getenv("BOT_TRANSPORTS")
+	transports, err := transport.Parse(config.Transports)
+	if err != nil {
+		slog.Error("invalid notification transports in the environment (BOT_TRANSPORTS)", "error", err)
+		os.Exit(1)
+	}
+
+	// Routing of notifications to chats by game attributes: JSON array or path to JSON file, see [entity.Route]
+	if routes := strings.TrimSpace(os.Getenv("BOT_ROUTES")); len(routes) > 0 {
+		data := []byte(routes)
+		if !strings.HasPrefix(routes, "[") {
+			var err error
+			if data, err = os.ReadFile(routes); err != nil {
+				slog.Error("cannot read notification routes file (BOT_ROUTES)", "error", err)
+				os.Exit(1)
+			}
+		}
+		parsed, err := entity.ParseRoutes(data)
+		if err != nil {
+			slog.Error("invalid notification routes (BOT_ROUTES)", "error", err)
+			os.Exit(1)
+		}
+		config.Routes = parsed
+	}
+
+	// Directory with message templates replacing the embedded ones, optional
+	config.TemplatesDir = os.Getenv("BOT_TEMPLATES")
+	set, err := templates.Load(config.TemplatesDir)
+	if err != nil {
+		slog.Error("invalid message templates (BOT_TEMPLATES)", "error", err)
+		os.Exit(1)
+	}
+	templates.Use(set)
+
+	// Locale of notification chats, other transports and users without known language
+	config.Locale = i18n.Default
+	if locale := os.Getenv("BOT_LOCALE"); len(locale) > 0 {
+		parsed, ok := i18n.Parse(locale)
+		if !ok {
+			slog.Error("unsupported locale in the environment (BOT_LOCALE)", "value", locale, "supported", i18n.Locales())
+			os.Exit(1)
+		}
+		config.Locale = parsed
+	}
+
+	// Locales of particular chats: "chat_id1:en;chat_id2:ru"
+	chatLocales, err := parseChatLocales(os.Getenv("BOT_CHAT_LOCALES"))
+	if err != nil {
+		slog.Error("invalid chat locales in the environment (BOT_CHAT_LOCALES)", "error", err)
+		os.Exit(1)
+	}
+	config.ChatLocales = chatLocales
+
+	// Bulk release, e.g. festival program: new games are announced together instead of a message per game
+	config.BulkReleaseRun = parseNonNegative("BOT_BULK_RELEASE_RUN", 10)
+	config.BulkReleasePage = parseNonNegative("BOT_BULK_RELEASE_PAGE", 5)
+
+	// Sanity limits of the absent games check: anomalous run cancels nothing and alerts the admin chat
+	config.AbsenceGuard = entity.AbsenceGuard{
+		MaxAbsent:        parseNonNegative("BOT_ABSENT_MAX_COUNT", 20),
+		MaxAbsentPercent: parseNonNegative("BOT_ABSENT_MAX_PERCENT", 30),
+		Runs:             max(1, parseNonNegative("BOT_ABSENT_RUNS", 2)),
+	}
+	config.AdminChatID = os.Getenv("BOT_ADMIN_CHAT_ID")
+	for _, pair := range strings.Split(config.AdminChatID, ";") {
+		if len(config.AdminChatID) > 0 && !strings.Contains(pair, ",") {
+			slog.Error("admin chat must be in the format chat_id,thread_id (BOT_ADMIN_CHAT_ID)", "value", config.AdminChatID)
+			os.Exit(1)
+		}
+	}
+
+	// Retries of the scraper requests: attempts per request, delay before the second attempt and total time limit
+	config.FetchRetry = scraper.DefaultRetryPolicy()
+	config.FetchRetry.Attempts = max(1, parseNonNegative("BOT_FETCH_ATTEMPTS", config.FetchRetry.Attempts))
+	config.FetchRetry.BaseDelay = parseDuration("BOT_FETCH_BACKOFF", config.FetchRetry.BaseDelay)
+	config.FetchRetry.MaxElapsed = parseDuration("BOT_FETCH_RETRY_TIMEOUT", config.FetchRetry.MaxElapsed)
+	config.FetchTimeout = parseDuration("BOT_FETCH_TIMEOUT", 10*time.Minute)
+	config.FetchCacheDir = os.Getenv("BOT_FETCH_CACHE")
+
+	// Polite crawling: request rate per host and contact address of the bot operator in User-Agent
+	config.FetchRPS = 1
+	if rps := os.Getenv("BOT_FETCH_RPS"); len(rps) > 0 {
+		parsed, err := strconv.ParseFloat(rps, 64)
+		if err != nil || parsed < 0 {
+			slog.Error("value must be a non-negative number of requests per second (BOT_FETCH_RPS)", "value", rps)
+			os.Exit(1)
+		}
+		config.FetchRPS = parsed
+	}
+	config.FetchBurst = max(1, parseNonNegative("BOT_FETCH_BURST", 3))
+	config.FetchContact = os.Getenv("BOT_FETCH_CONTACT")
+	if len(config.FetchContact) == 0 {
+		config.FetchContact = "https://github.com/kettari/location-bot"
+	}
+
 	slog.Debug("configuration parameters",
 		"BOT_DEBUG", config.Debug,
 		"BOT_DRY_RUN", config.DryRun,
@@ -81,7 +266,142 @@ func GetConfig() *Config {
 		"BOT_TELEGRAM_NAME", config.BotUsername,
 		"BOT_OPENAI_API_KEY", config.OpenAIApiKey,
 		"BOT_DB_STRING", config.DbConnectionString,
-		"BOT_NOTIFICATION_CHAT_ID", config.NotificationChatID)
+		"BOT_NOTIFICATION_CHAT_ID", config.NotificationChatID,
+		"BOT_FETCH_INTERVAL", config.FetchInterval,
+		"BOT_WEBHOOK_URL", config.WebhookURL,
+		"BOT_WEBHOOK_LISTEN", config.WebhookListen,
+		"BOT_WEBHOOK_TLS_CERT", config.WebhookTLSCert,
+		"BOT_BOARD", config.Board,
+		"BOT_BOARD_MESSAGES", config.BoardMessages,
+		// Transport URLs hold passwords and tokens, only their names are logged
+		"BOT_TRANSPORTS", transportNames(transports),
+		"BOT_ROUTES", len(config.Routes),
+		"BOT_TEMPLATES", config.TemplatesDir,
+		"BOT_LOCALE", config.Locale,
+		"BOT_CHAT_LOCALES", config.ChatLocales,
+		"BOT_BULK_RELEASE_RUN", config.BulkReleaseRun,
+		"BOT_BULK_RELEASE_PAGE", config.BulkReleasePage,
+		"BOT_ABSENT_MAX_COUNT", config.AbsenceGuard.MaxAbsent,
+		"BOT_ABSENT_MAX_PERCENT", config.AbsenceGuard.MaxAbsentPercent,
+		"BOT_ABSENT_RUNS", config.AbsenceGuard.Runs,
+		"BOT_ADMIN_CHAT_ID", config.AdminChatID,
+		"BOT_FETCH_ATTEMPTS", config.FetchRetry.Attempts,
+		"BOT_FETCH_BACKOFF", config.FetchRetry.BaseDelay,
+		"BOT_FETCH_RETRY_TIMEOUT", config.FetchRetry.MaxElapsed,
+		"BOT_FETCH_TIMEOUT", config.FetchTimeout,
+		"BOT_FETCH_CACHE", config.FetchCacheDir,
+		"BOT_FETCH_RPS", config.FetchRPS,
+		"BOT_FETCH_BURST", config.FetchBurst,
+		"BOT_FETCH_CONTACT", config.FetchContact)
 
 	return config
 }
+
+// transportNames returns names of the transports, e.g. "smtp:1a2b3c4d", which identify them without secrets
+func transportNames(transports []transport.Transport) []string {
+	names := make([]string, 0, len(transports))
+	for _, t := range transports {
+		names = append(names, t.Name())
+	}
+	return names
+}
+
+// parseNonNegative returns the environment variable as non-negative number or the default value if it is not set
+func parseNonNegative(name string, value int) int {
+	raw := os.Getenv(name)
+	if len(raw) == 0 {
+		return value
+	}
+	number, err := strconv.Atoi(raw)
+	if err != nil || number < 0 {
+		slog.Error("value must be a non-negative number ("+name+")", "value", raw)
+		os.Exit(1)
+	}
+	return number
+}
+
+// parseDuration returns the environment variable as non-negative duration or the default value if it is not set
+func parseDuration(name string, value time.Duration) time.Duration {
+	raw := os.Getenv(name)
+	if len(raw) == 0 {
+		return value
+	}
+	duration, err := time.ParseDuration(raw)
+	if err != nil || duration < 0 {
+		slog.Error("value must be a non-negative duration, e.g. 500ms or 1m ("+name+")", "value", raw)
+		os.Exit(1)
+	}
+	return duration
+}
+
+// parseChatLocales parses "chat_id1:en;chat_id2:ru" pairs
+func parseChatLocales(value string) (map[int64]i18n.Locale, error) {
+	result := map[int64]i18n.Locale{}
+	for _, pair := range strings.Split(value, ";") {
+		pair = strings.TrimSpace(pair)
+		if len(pair) == 0 {
+			continue
+		}
+		chat, code, found := strings.Cut(pair, ":")
+		chatID, err := strconv.ParseInt(strings.TrimSpace(chat), 10, 64)
+		if !found || err != nil {
+			return nil, fmt.Errorf("%q must be chat_id:locale", pair)
+		}
+		locale, ok := i18n.Parse(code)
+		if !ok {
+			return nil, fmt.Errorf("unsupported locale %q of chat %d", code, chatID)
+		}
+		result[chatID] = locale
+	}
+	return result, nil
+}
+
+// ChatLocale returns locale of the notification chat
+func (c *Config) ChatLocale(chatID int64) i18n.Locale {
+	if locale, ok := c.ChatLocales[chatID]; ok {
+		return locale
+	}
+	return c.Locale
+}
+
+// RecipientLocales returns distinct locales of the recipients "chat_id1,thread_id1;chat_id2,thread_id2"
+// in the order of recipients
+func (c *Config) RecipientLocales(recipients string) []i18n.Locale {
+	var locales []i18n.Locale
+	for _, pair := range strings.Split(recipients, ";") {
+		if locale := c.ChatLocale(recipientChatID(pair)); !slices.Contains(locales, locale) {
+			locales = append(locales, locale)
+		}
+	}
+	return locales
+}
+
+// RecipientsIn returns the recipients "chat_id1,thread_id1;chat_id2,thread_id2" of chats in the locale,
+// empty string if there are none
+func (c *Config) RecipientsIn(recipients string, locale i18n.Locale) string {
+	var result []string
+	for _, pair := range strings.Split(recipients, ";") {
+		if c.ChatLocale(recipientChatID(pair)) == locale {
+			result = append(result, pair)
+		}
+	}
+	return strings.Join(result, ";")
+}
+
+// OutboxLocales returns locales of notification chats for [entity.OutboxRecorder.UseLocales]
+func (c *Config) OutboxLocales() entity.Locales {
+	locales := entity.Locales{Default: c.Locale}
+	for _, locale := range c.ChatLocales {
+		if !slices.Contains(locales.Chats, locale) {
+			locales.Chats = append(locales.Chats, locale)
+		}
+	}
+	slices.Sort(locales.Chats)
+	return locales
+}
+
+func recipientChatID(pair string) int64 {
+	chat, _, _ := strings.Cut(pair, ",")
+	chatID, _ := strconv.ParseInt(strings.TrimSpace(chat), 10, 64)
+	return chatID
+}
@@ -1,11 +1,13 @@
 package console
 
 import (
+	"context"
 	"log/slog"
 	"time"
 
 	"github.com/kettari/location-bot/internal/config"
 	"github.com/kettari/location-bot/internal/handler"
+	"github.com/kettari/location-bot/internal/storage"
 	tele "gopkg.in/telebot.v4"
 )
 
@@ -27,7 +29,7 @@ func (cmd *BotPollCommand) Description() string {
 	return "polls Telegram Bot API for messages and processes them"
 }
 
-func (cmd *BotPollCommand) Run() error {
+func (cmd *BotPollCommand) Run(ctx context.Context) error {
 	conf := config.GetConfig()
 
 	slog.Info("starting the bot")
@@ -41,13 +43,12 @@ func (cmd *BotPollCommand) Run() error {
 		return err
 	}
 
-	// List bot commands
-	b.Handle("/help", handler.NewHelpHandler())
-	b.Handle("/start", handler.NewStartHandler())
-	b.Handle("/games", handler.NewGamesHandler())
+	manager := storage.NewManager(conf.DbConnectionString)
+	defer closeManager(manager)
+	registerHandlers(b, manager)
 
 	// Gracefully shutdown the bot after timeout
-	go stopPoll(b)
+	go stopPoll(ctx, b)
 	// Start poll
 	b.Start()
 
@@ -56,11 +57,40 @@ func (cmd *BotPollCommand) Run() error {
 	return nil
 }
 
-// stopPoll after timeout
-func stopPoll(bot *tele.Bot) {
+// registerHandlers lists bot commands
+func registerHandlers(b *tele.Bot, manager *storage.Manager) {
+	b.Use(handler.NewLocaleMiddleware(manager))
+	b.Handle("/help", handler.NewHelpHandler())
+	b.Handle("/start", handler.NewStartHandler(manager))
+	b.Handle("/games", handler.NewGamesHandler(manager))
+	b.Handle(&handler.GamesButton, handler.NewGamesCallbackHandler(manager))
+	b.Handle("/game", handler.NewGameHandler(manager))
+	b.Handle("/search", handler.NewSearchHandler(manager))
+	b.Handle("/subscribe", handler.NewSubscribeHandler(manager))
+	b.Handle("/unsubscribe", handler.NewUnsubscribeHandler(manager))
+	b.Handle("/subscriptions", handler.NewSubscriptionsHandler(manager))
+	b.Handle("/watch", handler.NewWatchHandler(manager))
+	b.Handle("/unwatch", handler.NewUnwatchHandler(manager))
+	b.Handle("/lang", handler.NewLangHandler(manager))
+	b.Handle("/settings", handler.NewSettingsHandler(manager))
+	b.Handle(&handler.SettingsButton, handler.NewSettingsCallbackHandler(manager))
+}
+
+// closeManager closes the connection pool of the manager when the command is done with it
+func closeManager(manager *storage.Manager) {
+	if err := manager.Close(); err != nil {
+		slog.Error("cannot close database connection", "error", err)
+	}
+}
+
+// stopPoll after timeout or when ctx is cancelled
+func stopPoll(ctx context.Context, bot *tele.Bot) {
 	stop := time.After(pollTimeout * time.Second)
 	slog.Info("timeout for shutdown started", "timeout_seconds", pollTimeout)
-	<-stop
+	select {
+	case <-stop:
+	case <-ctx.Done():
+	}
 	slog.Info("stopping the poll")
 	bot.Stop()
 }
@@ -0,0 +1,158 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/kettari/location-bot/internal/bot"
+	"github.com/kettari/location-bot/internal/config"
+	"github.com/kettari/location-bot/internal/storage"
+	tele "gopkg.in/telebot.v4"
+	"gopkg.in/telebot.v4/middleware"
+)
+
+const (
+	servePollTimeout = 30 * time.Second
+	serveMaxBackoff  = 1 * time.Minute
+)
+
+type BotServeCommand struct {
+}
+
+func NewBotServeCommand() *BotServeCommand {
+	cmd := BotServeCommand{}
+	return &cmd
+}
+
+func (cmd *BotServeCommand) Name() string {
+	return "bot:serve"
+}
+
+func (cmd *BotServeCommand) Description() string {
+	return "runs Telegram bot until stopped, optionally fetching schedule every BOT_FETCH_INTERVAL"
+}
+
+func (cmd *BotServeCommand) Run(ctx context.Context) error {
+	return cmd.serve(ctx, bot.NewResilientPoller(servePollTimeout))
+}
+
+// failingPoller reports the failure which stopped it from receiving updates, see [bot.WebhookPoller]
+type failingPoller interface {
+	Failed() <-chan error
+}
+
+// serve runs the bot with the given poller until ctx is cancelled, i.e. SIGINT or SIGTERM,
+// or the poller fails; the poller failure is returned
+func (cmd *BotServeCommand) serve(ctx context.Context, poller tele.Poller) error {
+	conf := config.GetConfig()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	slog.Info("starting the bot daemon")
+	b, err := cmd.createBot(ctx, conf.BotToken, poller)
+	if err != nil {
+		return err
+	}
+	b.Use(middleware.Recover(func(err error, c tele.Context) {
+		slog.Error("bot handler panic recovered", "error", err)
+	}))
+	// Handlers and fetch runs share one connection pool for the life of the daemon
+	manager := storage.NewManager(conf.DbConnectionString)
+	defer closeManager(manager)
+	registerHandlers(b, manager)
+
+	var wg sync.WaitGroup
+	if conf.FetchInterval > 0 {
+		wg.Add(1)
+		go cmd.fetchLoop(ctx, manager, conf.FetchInterval, &wg)
+	} else {
+		slog.Info("in-process schedule fetch disabled, BOT_FETCH_INTERVAL is not set")
+	}
+
+	var failed <-chan error
+	if p, ok := poller.(failingPoller); ok {
+		failed = p.Failed()
+	}
+	var pollErr error
+	go func() {
+		select {
+		case <-ctx.Done():
+			slog.Info("shutdown signal received, stopping the bot")
+		case pollErr = <-failed:
+			slog.Error("bot poller failed, stopping the bot", "error", pollErr)
+			cancel()
+		}
+		b.Stop()
+	}()
+	b.Start()
+
+	// Let the current fetch run stop
+	wg.Wait()
+	slog.Info("bot stopped, exiting")
+
+	return pollErr
+}
+
+// createBot retries bot creation while Telegram is unreachable
+func (cmd *BotServeCommand) createBot(ctx context.Context, token string, poller tele.Poller) (*tele.Bot, error) {
+	pref := tele.Settings{
+		Token:  token,
+		Poller: poller,
+		OnError: func(err error, c tele.Context) {
+			slog.Error("bot processing error", "error", err)
+		},
+	}
+	backoff := time.Second
+	for {
+		b, err := tele.NewBot(pref)
+		if err == nil {
+			return b, nil
+		}
+		// Telegram answered with an error, e.g. invalid token: retrying will not help
+		var telegramErr *tele.Error
+		if errors.As(err, &telegramErr) {
+			slog.Error("unable to create bot processor object", "error", err)
+			return nil, err
+		}
+		slog.Warn("unable to reach Telegram, retrying", "error", err, "retry_in", backoff)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, serveMaxBackoff)
+	}
+}
+
+// fetchLoop runs schedule:fetch immediately and then every interval until ctx is cancelled
+func (cmd *BotServeCommand) fetchLoop(ctx context.Context, manager *storage.Manager, interval time.Duration, wg *sync.WaitGroup) {
+	defer wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		cmd.fetch(ctx, manager)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fetch runs single schedule:fetch with the manager of the daemon; failures and panics must not stop the daemon
+func (cmd *BotServeCommand) fetch(ctx context.Context, manager *storage.Manager) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("schedule fetch panic recovered", "panic", r)
+		}
+	}()
+	slog.Info("running in-process schedule fetch")
+	fetch := NewScheduleFetchCommand()
+	fetch.manager = manager
+	if err := fetch.Run(ctx); err != nil {
+		slog.Error("in-process schedule fetch failed", "error", err)
+	}
+}
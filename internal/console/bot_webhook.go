@@ -0,0 +1,44 @@
+package console
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kettari/location-bot/internal/bot"
+	"github.com/kettari/location-bot/internal/config"
+)
+
+type BotWebhookCommand struct {
+	serve BotServeCommand
+}
+
+func NewBotWebhookCommand() *BotWebhookCommand {
+	cmd := BotWebhookCommand{}
+	return &cmd
+}
+
+func (cmd *BotWebhookCommand) Name() string {
+	return "bot:webhook"
+}
+
+func (cmd *BotWebhookCommand) Description() string {
+	return "runs Telegram bot receiving updates with webhook at BOT_WEBHOOK_LISTEN until stopped"
+}
+
+func (cmd *BotWebhookCommand) Run(ctx context.Context) error {
+	conf := config.GetConfig()
+	if len(conf.WebhookURL) == 0 {
+		return errors.New("webhook public URL is not set in the environment (BOT_WEBHOOK_URL)")
+	}
+	if len(conf.WebhookSecret) == 0 {
+		return errors.New("webhook secret is not set in the environment (BOT_WEBHOOK_SECRET)")
+	}
+
+	return cmd.serve.serve(ctx, &bot.WebhookPoller{
+		Listen:      conf.WebhookListen,
+		PublicURL:   conf.WebhookURL,
+		SecretToken: conf.WebhookSecret,
+		TLSCert:     conf.WebhookTLSCert,
+		TLSKey:      conf.WebhookTLSKey,
+	})
+}
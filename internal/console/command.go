@@ -1,7 +1,9 @@
 package console
 
+import "context"
+
 type Command interface {
 	Name() string
 	Description() string
-	Run() error
+	Run(ctx context.Context) error
 }
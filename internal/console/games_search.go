@@ -0,0 +1,72 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kettari/location-bot/internal/config"
+	"github.com/kettari/location-bot/internal/storage"
+)
+
+type GamesSearchCommand struct {
+}
+
+func NewGamesSearchCommand() *GamesSearchCommand {
+	cmd := GamesSearchCommand{}
+	return &cmd
+}
+
+func (cmd *GamesSearchCommand) Name() string {
+	return "games:search"
+}
+
+func (cmd *GamesSearchCommand) Description() string {
+	return "full-text search over games: games:search [--all] [--limit=N] <query>"
+}
+
+func (cmd *GamesSearchCommand) Run(ctx context.Context) error {
+	flags := flag.NewFlagSet(cmd.Name(), flag.ContinueOnError)
+	includePast := flags.Bool("all", false, "include past games")
+	limit := flags.Int("limit", 20, "maximum number of results")
+	if err := flags.Parse(os.Args[2:]); err != nil {
+		return err
+	}
+	query := strings.Join(flags.Args(), " ")
+	if len(strings.TrimSpace(query)) == 0 {
+		return errors.New("search query is empty")
+	}
+
+	slog.Info("searching games", "query", query, "include_past", *includePast, "limit", *limit)
+	conf := config.GetConfig()
+	manager := storage.NewManager(conf.DbConnectionString)
+	games, err := manager.SearchGames(query, *includePast, *limit)
+	if err != nil {
+		return err
+	}
+
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		return err
+	}
+	for _, game := range games {
+		fmt.Printf("#%d\t%s\t%d/%d\t%s [%s; %s] — %s\t%s\n",
+			game.ID,
+			game.Date.In(moscow).Format("02.01.2006 15:04"),
+			game.SeatsFree,
+			game.SeatsTotal,
+			game.Title,
+			game.System,
+			game.Setting,
+			game.MasterName,
+			game.URL)
+	}
+	slog.Info("search finished", "games_count", len(games))
+
+	return nil
+}
@@ -1,5 +1,7 @@
 package console
 
+import "context"
+
 type HelpCommand struct {
 }
 
@@ -16,6 +18,6 @@ func (cmd *HelpCommand) Description() string {
 	return "dummy command for help"
 }
 
-func (cmd *HelpCommand) Run() error {
+func (cmd *HelpCommand) Run(ctx context.Context) error {
 	return nil
 }
@@ -1,6 +1,7 @@
 package console
 
 import (
+	"context"
 	"github.com/kettari/location-bot/internal/config"
 	"github.com/kettari/location-bot/internal/entity"
 	"github.com/kettari/location-bot/internal/storage"
@@ -23,7 +24,7 @@ func (cmd *MigrateCommand) Description() string {
 	return "migrates GORM database scheme"
 }
 
-func (cmd *MigrateCommand) Run() error {
+func (cmd *MigrateCommand) Run(ctx context.Context) error {
 	slog.Info("migrating GORM database scheme")
 
 	conf := config.GetConfig()
@@ -31,7 +32,10 @@ func (cmd *MigrateCommand) Run() error {
 	if err := manager.Connect(); err != nil {
 		return err
 	}
-	if err := manager.DB().AutoMigrate(&entity.Game{}); err != nil {
+	if err := manager.DB().AutoMigrate(&entity.Game{}, &entity.Subscription{}, &entity.Watch{}, &entity.Notification{}, &entity.Announcement{}, &entity.BoardMessage{}, &entity.UserSettings{}, &entity.Digest{}, &entity.FetchAnomaly{}); err != nil {
+		return err
+	}
+	if err := manager.MigrateSearch(); err != nil {
 		return err
 	}
 
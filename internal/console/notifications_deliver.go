@@ -0,0 +1,363 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kettari/location-bot/internal/bot"
+	"github.com/kettari/location-bot/internal/config"
+	"github.com/kettari/location-bot/internal/entity"
+	"github.com/kettari/location-bot/internal/i18n"
+	"github.com/kettari/location-bot/internal/storage"
+	"github.com/kettari/location-bot/internal/transport"
+)
+
+const (
+	notificationsBatchSize = 50
+	// notificationsLease hides claimed notifications from other workers while they are being sent
+	notificationsLease = 5 * time.Minute
+	// telegramChannel is the name of notification chats in [entity.Notification] delivered channels;
+	// chats which got the notification before the others failed are listed as "telegram:chat_id/thread_id"
+	telegramChannel = "telegram"
+	// digestPartLimit leaves room below the Telegram 4096 characters limit when the digest is split into messages
+	digestPartLimit = 3800
+)
+
+type NotificationsDeliverCommand struct {
+	broadcast entity.AnnouncementDispatcher
+	// routed are dispatchers of route destinations, created on demand
+	routed     map[string]entity.AnnouncementDispatcher
+	direct     entity.DirectMessageDispatcher
+	transports []transport.Transport
+	// limiter is shared by the bots of the command and other bots of the process
+	limiter *bot.Limiter
+}
+
+func NewNotificationsDeliverCommand() *NotificationsDeliverCommand {
+	cmd := NotificationsDeliverCommand{}
+	return &cmd
+}
+
+func (cmd *NotificationsDeliverCommand) Name() string {
+	return "notifications:deliver"
+}
+
+func (cmd *NotificationsDeliverCommand) Description() string {
+	return "sends pending notifications from the outbox, retrying failed ones"
+}
+
+func (cmd *NotificationsDeliverCommand) Run(ctx context.Context) error {
+	conf := config.GetConfig()
+	if conf.DryRun {
+		slog.Info("DRY RUN MODE: skipping notifications delivery")
+		return nil
+	}
+
+	manager := storage.NewManager(conf.DbConnectionString)
+	if err := manager.Connect(); err != nil {
+		return err
+	}
+	return cmd.deliver(manager.WithContext(ctx))
+}
+
+// deliver sends due notifications until none is left; failed ones are rescheduled and do not block others
+func (cmd *NotificationsDeliverCommand) deliver(manager *storage.Manager) error {
+	delivered, failed := 0, 0
+	for {
+		notifications, err := manager.ClaimNotifications(notificationsBatchSize, notificationsLease)
+		if err != nil {
+			return err
+		}
+		if len(notifications) == 0 {
+			break
+		}
+		if err = cmd.createBots(); err != nil {
+			return err
+		}
+
+		// Due digest notifications of the user are sent together as one message
+		digests := map[int64][]*entity.Notification{}
+		var digestChats []int64
+		for k := range notifications {
+			notification := &notifications[k]
+			if notification.Digest && notification.ChatID != 0 {
+				if _, ok := digests[notification.ChatID]; !ok {
+					digestChats = append(digestChats, notification.ChatID)
+				}
+				digests[notification.ChatID] = append(digests[notification.ChatID], notification)
+				continue
+			}
+			sendErr, err := cmd.send(manager, notification)
+			if err != nil {
+				return err
+			}
+			if err = cmd.complete(manager, notification, sendErr, &delivered, &failed); err != nil {
+				return err
+			}
+		}
+		for _, chatID := range digestChats {
+			// Notifications of the sent part are delivered; the failed part and the rest are retried later
+			var sendErr error
+			for _, part := range digestParts(digests[chatID]) {
+				if sendErr == nil {
+					sendErr = cmd.direct.SendTo(chatID, []string{part.text})
+				}
+				for _, notification := range part.notifications {
+					if err = cmd.complete(manager, notification, sendErr, &delivered, &failed); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	slog.Info("notifications delivered", "delivered_count", delivered, "failed_count", failed)
+
+	return nil
+}
+
+// complete records the delivery result of the notification and counts it
+func (cmd *NotificationsDeliverCommand) complete(manager *storage.Manager, notification *entity.Notification, sendErr error, delivered, failed *int) error {
+	if sendErr != nil {
+		*failed++
+		permanent, retryAfter := classifyError(sendErr)
+		notification.Failed(time.Now(), sendErr, permanent, retryAfter)
+		slog.Warn("notification delivery failed",
+			"notification_id", notification.ID,
+			"game_id", notification.GameID,
+			"attempts", notification.Attempts,
+			"status", notification.Status,
+			"next_attempt_at", notification.NextAttemptAt,
+			"error", sendErr)
+	} else {
+		*delivered++
+		notification.Delivered(time.Now())
+	}
+	return manager.SaveNotification(notification)
+}
+
+// digestPart is the message of the digest with notifications it shows
+type digestPart struct {
+	text          string
+	notifications []*entity.Notification
+}
+
+// digestParts joins texts of the digest notifications under the header in the locale of the first one,
+// splitting them into messages not longer than digestPartLimit characters. The header opens the first message,
+// and the notification longer than the limit is split too: it is delivered with the part showing its end
+func digestParts(notifications []*entity.Notification) []digestPart {
+	var parts []digestPart
+	var current digestPart
+	for k, notification := range notifications {
+		text := notification.Text
+		if k == 0 {
+			text = i18n.T(notification.Locale, "digest.header", len(notifications)) + "\n\n" + text
+		}
+		chunks := transport.SplitText(text, digestPartLimit)
+		for _, chunk := range chunks {
+			switch {
+			case len(current.text) == 0:
+				current.text = chunk
+			case utf8.RuneCountInString(current.text)+2+utf8.RuneCountInString(chunk) > digestPartLimit:
+				parts = append(parts, current)
+				current = digestPart{text: chunk}
+			default:
+				current.text += "\n\n" + chunk
+			}
+		}
+		current.notifications = append(current.notifications, notification)
+	}
+	return append(parts, current)
+}
+
+// createBots creates dispatchers on the first due notification, so idle runs do not call Telegram
+func (cmd *NotificationsDeliverCommand) createBots() error {
+	if cmd.broadcast != nil {
+		return nil
+	}
+	conf := config.GetConfig()
+	if cmd.limiter == nil {
+		cmd.limiter = bot.NewLimiter()
+	}
+	broadcast, err := bot.CreateAnnouncementBot(conf.BotToken, conf.NotificationChatID, cmd.limiter)
+	if err != nil {
+		slog.Error("unable to create bot processor object", "error", err)
+		return err
+	}
+	direct, err := bot.CreateDirectBot(conf.BotToken, cmd.limiter)
+	if err != nil {
+		slog.Error("unable to create direct bot processor object", "error", err)
+		return err
+	}
+	transports, err := transport.Parse(conf.Transports)
+	if err != nil {
+		return err
+	}
+	cmd.broadcast, cmd.direct, cmd.transports = broadcast, direct, transports
+	cmd.routed = map[string]entity.AnnouncementDispatcher{}
+	return nil
+}
+
+// broadcastTo returns dispatcher of the recipients "chat_id1,thread_id1;chat_id2,thread_id2"
+func (cmd *NotificationsDeliverCommand) broadcastTo(destination string) (entity.AnnouncementDispatcher, error) {
+	if destination == config.GetConfig().NotificationChatID {
+		return cmd.broadcast, nil
+	}
+	if dispatcher, ok := cmd.routed[destination]; ok {
+		return dispatcher, nil
+	}
+	dispatcher, err := bot.CreateAnnouncementBot(config.GetConfig().BotToken, destination, cmd.limiter)
+	if err != nil {
+		return nil, err
+	}
+	cmd.routed[destination] = dispatcher
+	return dispatcher, nil
+}
+
+// send delivers the notification and returns delivery error; database error is returned separately
+// and stops the worker since the delivery result cannot be recorded
+func (cmd *NotificationsDeliverCommand) send(manager *storage.Manager, notification *entity.Notification) (sendErr error, err error) {
+	if notification.ChatID != 0 {
+		return cmd.direct.SendTo(notification.ChatID, []string{notification.Text}), nil
+	}
+
+	announcements, err := manager.FindAnnouncements(notification.GameID)
+	if err != nil {
+		return nil, err
+	}
+	if notification.Edit {
+		return cmd.editAnnouncements(manager, notification, announcements)
+	}
+
+	// Channels are independent: failure of one is retried without repeating the message in the others
+	var errs []error
+	if !slices.Contains(notification.DeliveredChannels(), telegramChannel) {
+		telegramErr, err := cmd.sendTelegram(manager, notification, announcements)
+		if err != nil {
+			return nil, err
+		}
+		if telegramErr != nil {
+			errs = append(errs, &transport.Error{
+				Transport:  telegramChannel,
+				Err:        telegramErr,
+				Permanent:  bot.IsPermanent(telegramErr),
+				RetryAfter: bot.RetryAfter(telegramErr),
+			})
+		} else {
+			notification.AddDeliveredChannels(telegramChannel)
+		}
+	}
+	// Other transports get the notification in the default locale only
+	if len(notification.Locale) == 0 || notification.Locale == config.GetConfig().Locale {
+		delivered, transportErr := transport.Deliver(cmd.transports, notification.DeliveredChannels(), notification.IdempotencyKey, []string{notification.Text})
+		notification.AddDeliveredChannels(delivered...)
+		if transportErr != nil {
+			errs = append(errs, transportErr)
+		}
+	}
+	return errors.Join(errs...), nil
+}
+
+// sendTelegram delivers the broadcast notification to the notification chats or the route destination
+// in the locale of the notification
+func (cmd *NotificationsDeliverCommand) sendTelegram(manager *storage.Manager, notification *entity.Notification, announcements []entity.Announcement) (sendErr error, err error) {
+	conf := config.GetConfig()
+	destination := notification.Destination
+	if len(destination) == 0 {
+		destination = conf.NotificationChatID
+	}
+	if len(notification.Locale) > 0 {
+		if destination = conf.RecipientsIn(destination, notification.Locale); len(destination) == 0 {
+			slog.Debug("no chats in the notification locale", "notification_id", notification.ID, "locale", notification.Locale)
+			return nil, nil
+		}
+	}
+	broadcast, err := cmd.broadcastTo(destination)
+	if err != nil {
+		return nil, err
+	}
+
+	switch notification.Subject {
+	case entity.SubjectTypeNew, entity.SubjectTypeBecomeJoinable:
+		// Re-opened game with posted announcement is shown by editing it
+		if notification.Subject == entity.SubjectTypeBecomeJoinable && len(announcements) > 0 {
+			slog.Debug("notification replaced by announcement edit", "notification_id", notification.ID, "game_id", notification.GameID)
+			return nil, nil
+		}
+		// Chats which got the announcement during the previous attempt are skipped
+		posted, sendErr := broadcast.Announce([]string{notification.Text}, announcements)
+		for k := range posted {
+			posted[k].GameID = notification.GameID
+		}
+		return sendErr, manager.CreateAnnouncements(posted)
+	case entity.SubjectTypeCancelled:
+		// Routed cancellation goes to its destination, announcements are not edited for it
+		if len(announcements) > 0 && len(notification.Destination) == 0 {
+			slog.Debug("notification replaced by announcement edit", "notification_id", notification.ID, "game_id", notification.GameID)
+			return nil, nil
+		}
+	}
+	// Chats which got the notification during the previous attempt are skipped
+	sent, sendErr := broadcast.SendExcept([]string{notification.Text}, deliveredChats(notification))
+	for _, recipient := range sent {
+		notification.AddDeliveredChannels(telegramChannel + ":" + strings.ReplaceAll(recipient, ",", "/"))
+	}
+	return sendErr, nil
+}
+
+// deliveredChats returns notification chats "chat_id,thread_id" which already got the broadcast notification
+func deliveredChats(notification *entity.Notification) []string {
+	var chats []string
+	for _, channel := range notification.DeliveredChannels() {
+		if chat, ok := strings.CutPrefix(channel, telegramChannel+":"); ok {
+			chats = append(chats, strings.ReplaceAll(chat, "/", ","))
+		}
+	}
+	return chats
+}
+
+// classifyError returns whether the delivery error is permanent and the delay requested by the server
+func classifyError(err error) (permanent bool, retryAfter time.Duration) {
+	var transportErr *transport.Error
+	if errors.As(err, &transportErr) {
+		return transport.IsPermanent(err), transport.RetryAfter(err)
+	}
+	return bot.IsPermanent(err), bot.RetryAfter(err)
+}
+
+// editAnnouncements replaces text of posted announcements of the game. Announcements which cannot be edited
+// anymore are forgotten; edits superseded by later ones are skipped to not show stale seats
+func (cmd *NotificationsDeliverCommand) editAnnouncements(manager *storage.Manager, notification *entity.Notification, announcements []entity.Announcement) (sendErr error, err error) {
+	if len(announcements) == 0 {
+		return nil, nil
+	}
+	newer, err := manager.HasNewerEdit(notification)
+	if err != nil || newer {
+		return nil, err
+	}
+
+	conf := config.GetConfig()
+	for k := range announcements {
+		announcement := &announcements[k]
+		if len(notification.Locale) > 0 && conf.ChatLocale(announcement.ChatID) != notification.Locale {
+			continue
+		}
+		editErr := cmd.broadcast.EditAnnouncement(announcement, notification.Text)
+		switch {
+		case editErr == nil:
+		case bot.IsPermanent(editErr):
+			slog.Warn("announcement cannot be edited, forgetting it",
+				"game_id", notification.GameID, "chat_id", announcement.ChatID, "message_id", announcement.MessageID, "error", editErr)
+			if err = manager.DeleteAnnouncement(announcement); err != nil {
+				return nil, err
+			}
+		default:
+			sendErr = editErr
+		}
+	}
+	return sendErr, nil
+}
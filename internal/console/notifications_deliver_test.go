@@ -0,0 +1,44 @@
+package console
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/kettari/location-bot/internal/entity"
+	"github.com/kettari/location-bot/internal/i18n"
+)
+
+func TestDigestParts(t *testing.T) {
+	long := &entity.Notification{Locale: i18n.English, Text: strings.Repeat("строка игры\n", 500)}
+	short := &entity.Notification{Locale: i18n.English, Text: "короткое"}
+	parts := digestParts([]*entity.Notification{long, short})
+
+	if len(parts) < 2 {
+		t.Fatalf("got %d parts, want the long notification split", len(parts))
+	}
+	if !strings.HasPrefix(parts[0].text, "Notification digest (2):\n\nстрока игры") {
+		t.Errorf("first part does not open with the header and the notification: %q", parts[0].text[:60])
+	}
+	var text []string
+	for k, part := range parts {
+		if n := utf8.RuneCountInString(part.text); n > digestPartLimit {
+			t.Errorf("part %d has %d characters, want at most %d", k, n, digestPartLimit)
+		}
+		text = append(text, part.text)
+	}
+	if joined := strings.Join(text, "\n"); strings.Count(joined, "строка игры") != 500 || !strings.HasSuffix(joined, "короткое") {
+		t.Error("digest parts lost text")
+	}
+
+	// The notification is delivered with the part showing its end
+	last := parts[len(parts)-1]
+	if len(last.notifications) != 2 || last.notifications[0] != long || last.notifications[1] != short {
+		t.Errorf("last part notifications = %v", last.notifications)
+	}
+	for _, part := range parts[:len(parts)-1] {
+		if len(part.notifications) != 0 {
+			t.Errorf("part with the beginning of the long notification completes %v", part.notifications)
+		}
+	}
+}
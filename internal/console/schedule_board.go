@@ -0,0 +1,51 @@
+package console
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/kettari/location-bot/internal/bot"
+	"github.com/kettari/location-bot/internal/config"
+	"github.com/kettari/location-bot/internal/schedule"
+	"github.com/kettari/location-bot/internal/storage"
+)
+
+type ScheduleBoardCommand struct {
+	// limiter is shared with other bots of the process, nil means the board bots have their own one
+	limiter *bot.Limiter
+	// manager is shared with schedule:fetch, nil means the command opens its own and closes it when done
+	manager *storage.Manager
+}
+
+func NewScheduleBoardCommand() *ScheduleBoardCommand {
+	cmd := ScheduleBoardCommand{}
+	return &cmd
+}
+
+func (cmd *ScheduleBoardCommand) Name() string {
+	return "schedule:board"
+}
+
+func (cmd *ScheduleBoardCommand) Description() string {
+	return "updates the pinned schedule board in the notification chats"
+}
+
+func (cmd *ScheduleBoardCommand) Run(ctx context.Context) error {
+	slog.Info("updating schedule board")
+
+	conf := config.GetConfig()
+	manager := cmd.manager
+	if manager == nil {
+		manager = storage.NewManager(conf.DbConnectionString)
+		defer closeManager(manager)
+	}
+	sch := schedule.NewSchedule(manager)
+	if cmd.limiter != nil {
+		sch.UseLimiter(cmd.limiter)
+	}
+	if err := sch.LoadJoinableEvents(schedule.EventFilter{}); err != nil {
+		return err
+	}
+
+	return sch.UpdateBoard(conf.NotificationChatID, conf.BoardMessages)
+}
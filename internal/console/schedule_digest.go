@@ -0,0 +1,49 @@
+package console
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/kettari/location-bot/internal/config"
+	"github.com/kettari/location-bot/internal/entity"
+	"github.com/kettari/location-bot/internal/schedule"
+	"github.com/kettari/location-bot/internal/storage"
+)
+
+type ScheduleDigestCommand struct {
+}
+
+func NewScheduleDigestCommand() *ScheduleDigestCommand {
+	cmd := ScheduleDigestCommand{}
+	return &cmd
+}
+
+func (cmd *ScheduleDigestCommand) Name() string {
+	return "schedule:digest"
+}
+
+func (cmd *ScheduleDigestCommand) Description() string {
+	return "sends summary of schedule changes since the last digest to the Telegram bot: schedule:digest [--period=day|week]"
+}
+
+func (cmd *ScheduleDigestCommand) Run(ctx context.Context) error {
+	flags := flag.NewFlagSet(cmd.Name(), flag.ContinueOnError)
+	period := flags.String("period", string(entity.DigestPeriodDay), "summarized period: day or week")
+	if err := flags.Parse(os.Args[2:]); err != nil {
+		return err
+	}
+	if *period != string(entity.DigestPeriodDay) && *period != string(entity.DigestPeriodWeek) {
+		return fmt.Errorf("unsupported period %s, expected day or week", *period)
+	}
+
+	slog.Info("running schedule digest", "period", *period)
+
+	conf := config.GetConfig()
+	manager := storage.NewManager(conf.DbConnectionString)
+	sch := schedule.NewSchedule(manager)
+
+	return sch.ExecuteDigest(entity.DigestPeriod(*period), conf.NotificationChatID)
+}
@@ -1,6 +1,7 @@
 package console
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"sync"
@@ -19,6 +20,12 @@ const (
 )
 
 type ScheduleFetchCommand struct {
+	// cache of event pages, nil if disabled
+	cache *scraper.Cache
+	// client sends requests of the run with its retry policy and politeness
+	client *scraper.Client
+	// manager is shared by the bot daemon, nil means the run opens its own and closes it when done
+	manager *storage.Manager
 }
 
 type Job struct {
@@ -26,9 +33,10 @@ type Job struct {
 }
 
 type Result struct {
-	url  string
-	html string
-	err  error
+	url         string
+	html        string
+	notModified bool
+	err         error
 }
 
 func NewScheduleFetchCommand() *ScheduleFetchCommand {
@@ -44,74 +52,184 @@ func (cmd *ScheduleFetchCommand) Description() string {
 	return "fetches events from the Rolecon server and parses them to the database"
 }
 
-func (cmd *ScheduleFetchCommand) Run() error {
+func (cmd *ScheduleFetchCommand) Run(ctx context.Context) error {
 	slog.Info("fetching schedule")
 	conf := config.GetConfig()
+	if conf.FetchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, conf.FetchTimeout)
+		defer cancel()
+	}
+
+	cmd.client = scraper.NewClient(conf.FetchRetry, scraper.NewPoliteness(conf.FetchContact, conf.FetchRPS, conf.FetchBurst))
+	if len(conf.FetchCacheDir) > 0 {
+		cache, err := scraper.NewCache(conf.FetchCacheDir)
+		if err != nil {
+			return err
+		}
+		cmd.cache = cache
+	}
 
 	// Use fetcher service to orchestrate CSRF and events collection
-	fetcher := scraper.NewFetcher()
-	result, err := fetcher.FetchAll(func(urls []string) ([]scraper.Page, error) {
+	fetcher := scraper.NewFetcher(cmd.client)
+	fetcher.UseCache(cmd.cache)
+	result, err := fetcher.FetchAll(ctx, func(ctx context.Context, urls []string) ([]scraper.Page, []string, error) {
 		var pages []scraper.Page
-		errorFlag := false
-		cmd.dispatcher(urls, workersCount, &pages, &errorFlag)
-		if errorFlag {
-			slog.Warn("error flag was set, discarding results")
-			return nil, fmt.Errorf("failed to fetch some pages")
+		var failed []string
+		cmd.dispatcher(ctx, urls, workersCount, &pages, &failed)
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
 		}
-		return pages, nil
+		return pages, failed, nil
 	})
 	if err != nil {
 		return err
 	}
 
+	// Bots of the run share Telegram rate limits
+	limiter := bot.NewLimiter()
+
 	// Parsing pages
 	var sch *schedule.Schedule
+	var manager *storage.Manager
 	if !conf.DryRun {
-		manager := storage.NewManager(conf.DbConnectionString)
+		manager = cmd.manager
+		if manager == nil {
+			owned := storage.NewManager(conf.DbConnectionString)
+			defer closeManager(owned)
+			manager = owned
+		}
 		if err = manager.Connect(); err != nil {
 			return err
 		}
+		manager = manager.WithContext(ctx)
 		sch = schedule.NewSchedule(manager)
 	} else {
 		slog.Info("DRY RUN MODE: skipping database connection")
 		sch = schedule.NewSchedule(nil)
 	}
+	sch.UseLimiter(limiter)
 
 	// Parse pages with event metadata
 	prsr := parser.NewParser(parser.NewHtmlEngineV2())
-	err = prsr.ParseWithEvents(result, sch)
+	err = prsr.ParseWithEvents(ctx, result, sch)
 	if err != nil {
 		return err
 	}
+	sch.UseBulkRelease(conf.BulkReleaseRun, conf.BulkReleasePage)
 
-	// Register observers
-	// Create bot with dependency injection (token and recipients)
-	b, err := bot.CreateBot(conf.BotToken, conf.NotificationChatID)
-	if err != nil {
-		slog.Error("unable to create bot processor object", "error", err)
-		return err
+	// Register observers. With database notifications go to the outbox in the same transaction
+	// as the game and are sent afterwards; in dry run mode they are sent right away
+	var b entity.MessageDispatcher
+	var outbox *entity.OutboxRecorder
+	var users map[int64]entity.UserSettings
+	if manager != nil {
+		if users, err = manager.AllUserSettings(); err != nil {
+			return err
+		}
+		outbox = entity.NewOutboxRecorder()
+		outbox.UseRoutes(conf.Routes)
+		outbox.UseLocales(conf.OutboxLocales())
+		outbox.UseUsers(users)
+		sch.UseOutbox(outbox)
+		b = outbox
+	} else {
+		// Create bot with dependency injection (token and recipients)
+		if b, err = bot.CreateBot(conf.BotToken, conf.NotificationChatID, limiter); err != nil {
+			slog.Error("unable to create bot processor object", "error", err)
+			return err
+		}
 	}
+	newObserver := entity.NewGameObserver(b)
+	becomeJoinableObserver := entity.BecomeJoinableGameObserver(b)
+	cancelledObserver := entity.CancelledGameObserver(b)
 	for k := range sch.Games {
-		sch.Games[k].Register(entity.NewGameObserver(b))
-		sch.Games[k].Register(entity.BecomeJoinableGameObserver(b))
-		sch.Games[k].Register(entity.CancelledGameObserver(b))
+		sch.Games[k].Register(newObserver)
+		sch.Games[k].Register(becomeJoinableObserver)
+		sch.Games[k].Register(cancelledObserver)
+	}
+	if manager != nil {
+		announcementObserver := entity.AnnouncementGameObserver(outbox)
+		for k := range sch.Games {
+			sch.Games[k].Register(announcementObserver)
+		}
+		if err = cmd.registerPersonalObservers(outbox, manager, sch, users); err != nil {
+			return err
+		}
+	}
+
+	if err = sch.SaveGames(ctx); err != nil {
+		return err
 	}
 
-	if err = sch.SaveGames(); err != nil {
+	if err = sch.CheckAbsentGames(ctx, result.Failed, result.Unchanged); err != nil {
 		return err
 	}
 
-	if err = sch.CheckAbsentGames(); err != nil {
+	if err = sch.PurgeWatches(ctx); err != nil {
 		return err
 	}
 
-	slog.Info("schedule fetched successfully", "games_count", len(sch.Games))
+	if manager != nil {
+		// Unchanged pages are skipped by the next runs only after their games are saved
+		if err = cmd.cache.Commit(); err != nil {
+			slog.Error("cannot save event pages cache", "error", err)
+		}
+		deliver := NewNotificationsDeliverCommand()
+		deliver.limiter = limiter
+		if err = deliver.deliver(manager); err != nil {
+			return err
+		}
+		if conf.Board {
+			board := NewScheduleBoardCommand()
+			board.limiter = limiter
+			board.manager = manager
+			if err = board.Run(ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	slog.Info("schedule fetched successfully",
+		"games_count", len(sch.Games),
+		"pages_count", len(result.Pages),
+		"failed_pages_count", len(result.Failed),
+		"cache_hits", len(result.Unchanged),
+		"anomalous", sch.Anomalous())
 
 	return nil
 }
 
+// registerPersonalObservers registers observers for private notifications of subscribed and watching users;
+// subscriptions are matched in the time zones of the users settings
+func (cmd *ScheduleFetchCommand) registerPersonalObservers(direct entity.DirectMessageDispatcher, manager *storage.Manager, sch *schedule.Schedule, users map[int64]entity.UserSettings) error {
+	subscriptions, err := manager.AllSubscriptions()
+	if err != nil {
+		return err
+	}
+	watchers, err := manager.WatchersByGame()
+	if err != nil {
+		return err
+	}
+	if len(subscriptions) == 0 && len(watchers) == 0 {
+		return nil
+	}
+	slog.Debug("loaded personal notification settings", "subscriptions_count", len(subscriptions), "watched_games_count", len(watchers))
+
+	subscribersObserver := entity.SubscribersGameObserver(direct, subscriptions, users)
+	watchersObserver := entity.WatchersGameObserver(direct, watchers)
+	for k := range sch.Games {
+		sch.Games[k].Register(subscribersObserver)
+		sch.Games[k].Register(watchersObserver)
+	}
+	return nil
+}
+
+// dispatcher fetches urls with workerCount workers, fetched pages are collected to pages and URLs failed
+// to fetch to failed. After ctx is cancelled requests in flight are aborted and the remaining jobs fail without requests
+//
 // see [https://rksurwase.medium.com/efficient-concurrency-in-go-a-deep-dive-into-the-worker-pool-pattern-for-batch-processing-73cac5a5bdca]
-func (cmd *ScheduleFetchCommand) dispatcher(urls []string, workerCount int, pages *[]scraper.Page, errorFlag *bool) {
+func (cmd *ScheduleFetchCommand) dispatcher(ctx context.Context, urls []string, workerCount int, pages *[]scraper.Page, failed *[]string) {
 	jobs := make(chan Job, len(urls))
 	results := make(chan Result, len(urls))
 
@@ -120,13 +238,13 @@ func (cmd *ScheduleFetchCommand) dispatcher(urls []string, workerCount int, page
 	// Start workers
 	wg.Add(workerCount)
 	for w := 1; w <= workerCount; w++ {
-		go cmd.worker(w, jobs, results, &wg)
+		go cmd.worker(ctx, w, jobs, results, &wg)
 	}
 
 	// Start collecting results
 	var resultsWg sync.WaitGroup
 	resultsWg.Add(1)
-	go cmd.collector(results, &resultsWg, pages, errorFlag)
+	go cmd.collector(results, &resultsWg, pages, failed)
 
 	// Distribute jobs and wait for completion
 	for _, url := range urls {
@@ -140,27 +258,31 @@ func (cmd *ScheduleFetchCommand) dispatcher(urls []string, workerCount int, page
 	resultsWg.Wait()
 }
 
-func (cmd *ScheduleFetchCommand) worker(id int, jobs <-chan Job, results chan<- Result, wg *sync.WaitGroup) {
+func (cmd *ScheduleFetchCommand) worker(ctx context.Context, id int, jobs <-chan Job, results chan<- Result, wg *sync.WaitGroup) {
 	defer wg.Done()
 	for job := range jobs {
-		pageScraper := scraper.NewPage(job.url)
-		err := pageScraper.LoadHtml()
+		if err := ctx.Err(); err != nil {
+			results <- Result{url: job.url, html: "", err: fmt.Errorf("job url %s (worker %d) skipped: %w", job.url, id, err)}
+			continue
+		}
+		pageScraper := scraper.NewCachedPage(job.url, cmd.cache, cmd.client)
+		err := pageScraper.LoadHtml(ctx)
 		if err != nil {
 			results <- Result{url: job.url, html: "", err: fmt.Errorf("job url %s (worker %d) failed to scrape page: %w", job.url, id, err)}
 		} else {
-			results <- Result{url: job.url, html: pageScraper.Html, err: nil}
+			results <- Result{url: job.url, html: pageScraper.Html, notModified: pageScraper.NotModified, err: nil}
 		}
 	}
 }
 
-func (cmd *ScheduleFetchCommand) collector(results <-chan Result, wg *sync.WaitGroup, pages *[]scraper.Page, errorFlag *bool) {
+func (cmd *ScheduleFetchCommand) collector(results <-chan Result, wg *sync.WaitGroup, pages *[]scraper.Page, failed *[]string) {
 	defer wg.Done()
 	for result := range results {
 		if result.err == nil {
-			*pages = append(*pages, scraper.Page{URL: result.url, Html: result.html})
+			*pages = append(*pages, scraper.Page{URL: result.url, Html: result.html, NotModified: result.notModified})
 		} else {
 			slog.Warn("failed to fetch page", "url", result.url, "err", result.err)
-			*errorFlag = true
+			*failed = append(*failed, result.url)
 		}
 	}
 }
@@ -1,6 +1,7 @@
 package console
 
 import (
+	"context"
 	"github.com/kettari/location-bot/internal/config"
 	"github.com/kettari/location-bot/internal/schedule"
 	"github.com/kettari/location-bot/internal/storage"
@@ -23,13 +24,13 @@ func (cmd *ScheduleReportFullCommand) Description() string {
 	return "sends full notification to the Telegram bot"
 }
 
-func (cmd *ScheduleReportFullCommand) Run() error {
+func (cmd *ScheduleReportFullCommand) Run(ctx context.Context) error {
 	slog.Info("running full report")
 
 	conf := config.GetConfig()
 	manager := storage.NewManager(conf.DbConnectionString)
 	sch := schedule.NewSchedule(manager)
-	if err := sch.LoadJoinableEvents(); err != nil {
+	if err := sch.LoadJoinableEvents(schedule.EventFilter{}); err != nil {
 		return err
 	}
 
@@ -0,0 +1,47 @@
+package console
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+
+	"github.com/kettari/location-bot/internal/config"
+	"github.com/kettari/location-bot/internal/schedule"
+	"github.com/kettari/location-bot/internal/storage"
+)
+
+type ScheduleReportUnnotifiedCommand struct {
+}
+
+func NewScheduleReportUnnotifiedCommand() *ScheduleReportUnnotifiedCommand {
+	cmd := ScheduleReportUnnotifiedCommand{}
+	return &cmd
+}
+
+func (cmd *ScheduleReportUnnotifiedCommand) Name() string {
+	return "schedule:report:unnotified"
+}
+
+func (cmd *ScheduleReportUnnotifiedCommand) Description() string {
+	return "sends joinable games nobody was notified about to the Telegram bot: schedule:report:unnotified [--mark-only]"
+}
+
+func (cmd *ScheduleReportUnnotifiedCommand) Run(ctx context.Context) error {
+	flags := flag.NewFlagSet(cmd.Name(), flag.ContinueOnError)
+	markOnly := flags.Bool("mark-only", false, "record games as notified without sending")
+	if err := flags.Parse(os.Args[2:]); err != nil {
+		return err
+	}
+
+	slog.Info("running unnotified games report")
+
+	conf := config.GetConfig()
+	manager := storage.NewManager(conf.DbConnectionString)
+	sch := schedule.NewSchedule(manager)
+	if err := sch.LoadUnnotifiedEvents(conf.Routes, conf.NotificationChatID); err != nil {
+		return err
+	}
+
+	return sch.ExecuteUnnotifiedReport(*markOnly)
+}
@@ -0,0 +1,143 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/kettari/location-bot/internal/config"
+	"github.com/kettari/location-bot/internal/entity"
+	"github.com/kettari/location-bot/internal/i18n"
+	"github.com/kettari/location-bot/internal/schedule"
+	"github.com/kettari/location-bot/internal/storage"
+	"github.com/kettari/location-bot/internal/templates"
+)
+
+// gameTemplates are rendered for every previewed game
+var gameTemplates = []string{
+	templates.GameNew,
+	templates.GameFreeSeats,
+	templates.GameCancelled,
+	templates.GameAnnouncement,
+	templates.GameCard,
+	templates.ScheduleDate,
+	templates.ScheduleGame,
+}
+
+type TemplatesPreviewCommand struct {
+}
+
+func NewTemplatesPreviewCommand() *TemplatesPreviewCommand {
+	cmd := TemplatesPreviewCommand{}
+	return &cmd
+}
+
+func (cmd *TemplatesPreviewCommand) Name() string {
+	return "templates:preview"
+}
+
+func (cmd *TemplatesPreviewCommand) Description() string {
+	return "renders message templates against games from the database: templates:preview [--dir=path] [--locale=ru|en] [--template=name] [--game=ID] [--limit=N]"
+}
+
+func (cmd *TemplatesPreviewCommand) Run(ctx context.Context) error {
+	conf := config.GetConfig()
+	flags := flag.NewFlagSet(cmd.Name(), flag.ContinueOnError)
+	dir := flags.String("dir", conf.TemplatesDir, "templates directory, embedded templates are used for missing files")
+	name := flags.String("template", "", "render only this template")
+	gameID := flags.Uint("game", 0, "render the game with this ID instead of upcoming games")
+	limit := flags.Int("limit", 3, "number of upcoming games")
+	localeCode := flags.String("locale", string(conf.Locale), "locale of the templates")
+	if err := flags.Parse(os.Args[2:]); err != nil {
+		return err
+	}
+	locale, ok := i18n.Parse(*localeCode)
+	if !ok {
+		return fmt.Errorf("unsupported locale %s, expected one of %v", *localeCode, i18n.Locales())
+	}
+	if len(*name) > 0 && !slices.Contains(templates.Names(), *name) {
+		return fmt.Errorf("unknown template %s", *name)
+	}
+
+	set, err := templates.Load(*dir)
+	if err != nil {
+		return err
+	}
+	slog.Info("templates loaded", "dir", *dir, "locale", locale, "custom", set.Custom)
+
+	manager := storage.NewManager(conf.DbConnectionString)
+	if err = manager.Connect(); err != nil {
+		return err
+	}
+	var games []entity.Game
+	query := manager.DB().Order("date ASC")
+	if *gameID > 0 {
+		query = query.Where("id = ?", *gameID)
+	} else {
+		query = query.Where("date > ?", time.Now()).Limit(*limit)
+	}
+	if err = query.Find(&games).Error; err != nil {
+		return err
+	}
+	if len(games) == 0 {
+		return errors.New("no games to preview")
+	}
+
+	failed := 0
+	preview := func(template, subject string, data any) {
+		if len(*name) > 0 && *name != template {
+			return
+		}
+		text, err := set.Execute(locale, template, data)
+		if err != nil {
+			failed++
+			fmt.Printf("=== %s (%s): ERROR %s\n\n", template, subject, err)
+			return
+		}
+		fmt.Printf("=== %s (%s)\n%s\n\n", template, subject, text)
+	}
+
+	for k := range games {
+		game := &games[k]
+		subject := fmt.Sprintf("game #%d", game.ID)
+		for _, template := range gameTemplates {
+			if template == templates.ScheduleGame {
+				preview(template, subject, struct {
+					Game    *entity.Game
+					CardURL string
+				}{Game: game, CardURL: game.CardURL(conf.BotUsername)})
+				continue
+			}
+			preview(template, subject, game)
+		}
+	}
+	preview(templates.ScheduleHeader, "no data", nil)
+	preview(templates.ScheduleEmpty, "no data", nil)
+	preview(templates.ScheduleMore, "5 games", 5)
+	preview(templates.BoardUpdated, "now", time.Now())
+
+	// Composed schedule report of the upcoming joinable games
+	if len(*name) == 0 {
+		templates.Use(set)
+		sch := schedule.NewSchedule(manager)
+		sch.Locale = locale
+		if err = sch.LoadJoinableEvents(schedule.EventFilter{}); err != nil {
+			return err
+		}
+		parts, err := sch.Format()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("=== schedule report (%d games, %d messages)\n%s\n", len(sch.Games), len(parts), parts[0])
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d templates failed to render", failed)
+	}
+	return nil
+}
@@ -1,10 +1,12 @@
 package console
 
 import (
+	"context"
 	"fmt"
 	"github.com/kettari/location-bot/internal/scraper"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -53,12 +55,12 @@ func TestScheduleFetchCommand_dispatcher(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			cmd := &ScheduleFetchCommand{}
 			var pages []scraper.Page
-			errorFlag := false
+			var failed []string
 
-			cmd.dispatcher(tt.urls, tt.workerCount, &pages, &errorFlag)
+			cmd.dispatcher(context.Background(), tt.urls, tt.workerCount, &pages, &failed)
 
-			if errorFlag != tt.wantError {
-				t.Errorf("dispatcher() errorFlag = %v, want %v", errorFlag, tt.wantError)
+			if (len(failed) > 0) != tt.wantError {
+				t.Errorf("dispatcher() failed = %v, want error %v", failed, tt.wantError)
 			}
 
 			if !tt.wantError && len(pages) != len(tt.urls) {
@@ -81,10 +83,10 @@ func TestScheduleFetchCommand_dispatcher_ContextCancellation(t *testing.T) {
 	cmd := &ScheduleFetchCommand{}
 	urls := []string{delayedServer.URL + "/1", delayedServer.URL + "/2"}
 	var pages []scraper.Page
-	errorFlag := false
+	var failed []string
 
 	start := time.Now()
-	cmd.dispatcher(urls, 2, &pages, &errorFlag)
+	cmd.dispatcher(context.Background(), urls, 2, &pages, &failed)
 	elapsed := time.Since(start)
 
 	// Should complete without hanging
@@ -92,8 +94,35 @@ func TestScheduleFetchCommand_dispatcher_ContextCancellation(t *testing.T) {
 		t.Error("dispatcher() took too long, possible deadlock")
 	}
 
-	if errorFlag {
-		t.Error("dispatcher() set errorFlag unexpectedly")
+	if len(failed) > 0 {
+		t.Errorf("dispatcher() failed = %v, want none", failed)
+	}
+}
+
+func TestScheduleFetchCommand_dispatcher_CancelledContext(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cmd := &ScheduleFetchCommand{}
+	var pages []scraper.Page
+	var failed []string
+	cmd.dispatcher(ctx, []string{server.URL + "/1", server.URL + "/2", server.URL + "/3"}, 2, &pages, &failed)
+
+	if len(failed) != 3 {
+		t.Errorf("dispatcher() failed = %v, want all urls when context is cancelled", failed)
+	}
+	if len(pages) != 0 {
+		t.Errorf("dispatcher() collected %d pages, want 0", len(pages))
+	}
+	if got := requests.Load(); got != 0 {
+		t.Errorf("dispatcher() made %d requests after cancellation, want 0", got)
 	}
 }
 
@@ -119,13 +148,16 @@ func TestScheduleFetchCommand_dispatcher_ErrorHandling(t *testing.T) {
 		failingServer.URL + "/3", // This one will fail
 	}
 	var pages []scraper.Page
-	errorFlag := false
+	var failed []string
 
-	cmd.dispatcher(urls, 2, &pages, &errorFlag)
+	cmd.dispatcher(context.Background(), urls, 2, &pages, &failed)
 
-	// errorFlag should be true due to the failing request
-	if !errorFlag {
-		t.Error("dispatcher() should set errorFlag on errors")
+	// The failing request is recorded, pages fetched successfully are kept
+	if len(failed) != 1 {
+		t.Errorf("dispatcher() failed = %v, want 1 url", failed)
+	}
+	if len(pages) != 2 {
+		t.Errorf("dispatcher() collected %d pages, want 2", len(pages))
 	}
 }
 
@@ -146,14 +178,14 @@ func TestScheduleFetchCommand_dispatcher_Concurrency(t *testing.T) {
 
 	cmd := &ScheduleFetchCommand{}
 	var pages []scraper.Page
-	errorFlag := false
+	var failed []string
 
 	start := time.Now()
-	cmd.dispatcher(urls, 5, &pages, &errorFlag)
+	cmd.dispatcher(context.Background(), urls, 5, &pages, &failed)
 	elapsed := time.Since(start)
 
-	if errorFlag {
-		t.Error("dispatcher() set errorFlag unexpectedly")
+	if len(failed) > 0 {
+		t.Errorf("dispatcher() failed = %v, want none", failed)
 	}
 
 	if len(pages) != numUrls {
@@ -170,12 +202,12 @@ func TestScheduleFetchCommand_dispatcher_Concurrency(t *testing.T) {
 func TestScheduleFetchCommand_dispatcher_EmptyResults(t *testing.T) {
 	cmd := &ScheduleFetchCommand{}
 	var pages []scraper.Page
-	errorFlag := false
+	var failed []string
 
-	cmd.dispatcher([]string{}, 5, &pages, &errorFlag)
+	cmd.dispatcher(context.Background(), []string{}, 5, &pages, &failed)
 
-	if errorFlag {
-		t.Error("dispatcher() should not set errorFlag for empty urls")
+	if len(failed) > 0 {
+		t.Errorf("dispatcher() failed = %v, want none for empty urls", failed)
 	}
 
 	if len(pages) != 0 {
@@ -0,0 +1,15 @@
+package entity
+
+import (
+	"gorm.io/gorm"
+)
+
+// Announcement is the message about the game posted to a notification chat.
+// Later changes of the game edit it instead of posting new messages
+type Announcement struct {
+	gorm.Model
+	GameID    uint  `json:"game_id" gorm:"index;not null"`
+	ChatID    int64 `json:"chat_id" gorm:"not null"`
+	ThreadID  int   `json:"thread_id" gorm:"default:0;not null"`
+	MessageID int   `json:"message_id" gorm:"not null"`
+}
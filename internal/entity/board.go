@@ -0,0 +1,15 @@
+package entity
+
+import (
+	"gorm.io/gorm"
+)
+
+// BoardMessage is one of the fixed set of schedule board messages in the notification chat;
+// the message at position 0 is pinned
+type BoardMessage struct {
+	gorm.Model
+	ChatID    int64 `json:"chat_id" gorm:"uniqueIndex:idx_board_position;not null"`
+	ThreadID  int   `json:"thread_id" gorm:"uniqueIndex:idx_board_position;default:0;not null"`
+	Position  int   `json:"position" gorm:"uniqueIndex:idx_board_position;not null"`
+	MessageID int   `json:"message_id" gorm:"not null"`
+}
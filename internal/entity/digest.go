@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DigestPeriod is the period summarized by schedule:digest
+type DigestPeriod string
+
+const (
+	DigestPeriodDay  DigestPeriod = "day"
+	DigestPeriodWeek DigestPeriod = "week"
+)
+
+// Duration returns length of the period; the first digest of the period looks back this far
+func (p DigestPeriod) Duration() time.Duration {
+	if p == DigestPeriodWeek {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// Digest records the last sent digest of the period; the next digest of the period
+// summarizes history after Until, so consecutive digests never repeat items
+type Digest struct {
+	gorm.Model
+	Period DigestPeriod `json:"period" gorm:"size:8;uniqueIndex;not null"`
+	Until  time.Time    `json:"until" gorm:"not null"`
+}
@@ -1,5 +1,99 @@
 package entity
 
+import (
+	"time"
+
+	"github.com/kettari/location-bot/internal/i18n"
+)
+
 type MessageDispatcher interface {
 	Send([]string) error
 }
+
+// DirectMessageDispatcher sends messages to the chat chosen by the caller, e.g. a private chat with the user
+type DirectMessageDispatcher interface {
+	SendTo(chatID int64, notification []string) error
+}
+
+// AnnouncementDispatcher posts announcements to notification chats and edits them later
+type AnnouncementDispatcher interface {
+	MessageDispatcher
+	// Announce sends notification to notification chats except those listed in posted
+	// and returns announcements delivered successfully, even if some chats failed
+	Announce(notification []string, posted []Announcement) ([]Announcement, error)
+	// SendExcept sends notification to notification chats except recipients "chat_id,thread_id" listed in delivered
+	// and returns recipients the notification was delivered to, even if some chats failed
+	SendExcept(notification []string, delivered []string) ([]string, error)
+	EditAnnouncement(announcement *Announcement, text string) error
+}
+
+// AnnouncementEditor updates announcements of the game posted earlier to notification chats
+type AnnouncementEditor interface {
+	EditAnnouncements(game *Game, text string) error
+}
+
+// BoardDispatcher keeps the schedule board in notification chats up to date
+type BoardDispatcher interface {
+	// UpdateBoard edits posted board messages to show parts, posting missing ones, and removes posted messages
+	// past the parts from the chats. It returns current board messages and removed ones, even if some chats failed
+	UpdateBoard(posted []BoardMessage, parts []string) (board []BoardMessage, removed []BoardMessage, err error)
+}
+
+// Localized renders the message in the locale with dates in the time zone; nil location means the default one
+type Localized func(locale i18n.Locale, location *time.Location) string
+
+// LocalizedDispatcher sends the message to notification chats in the locale of each chat
+type LocalizedDispatcher interface {
+	SendLocalized(notification Localized) error
+}
+
+// LocalizedParts renders the message split into parts in the locale
+type LocalizedParts func(locale i18n.Locale) []string
+
+// LocalizedPartsDispatcher sends the message split into parts to notification chats in the locale of each chat
+type LocalizedPartsDispatcher interface {
+	SendPartsLocalized(notification LocalizedParts) error
+}
+
+// LocalizedDirectDispatcher sends the message to the chat in the locale and time zone of its user
+type LocalizedDirectDispatcher interface {
+	SendToLocalized(chatID int64, notification Localized) error
+}
+
+// LocalizedAnnouncementEditor updates announcements of the game in the locale of each chat
+type LocalizedAnnouncementEditor interface {
+	EditAnnouncementsLocalized(game *Game, text Localized) error
+}
+
+// sendLocalized sends the message in chat locales if the dispatcher supports them, otherwise in the default locale
+func sendLocalized(bot MessageDispatcher, notification Localized) error {
+	if localized, ok := bot.(LocalizedDispatcher); ok {
+		return localized.SendLocalized(notification)
+	}
+	return bot.Send([]string{notification(i18n.Default, nil)})
+}
+
+// SendPartsLocalized sends the message split into parts in chat locales if the dispatcher supports them,
+// otherwise in the default locale
+func SendPartsLocalized(bot MessageDispatcher, notification LocalizedParts) error {
+	if localized, ok := bot.(LocalizedPartsDispatcher); ok {
+		return localized.SendPartsLocalized(notification)
+	}
+	return bot.Send(notification(i18n.Default))
+}
+
+// sendToLocalized sends the message in the user locale if the dispatcher supports it, otherwise in the default locale
+func sendToLocalized(bot DirectMessageDispatcher, chatID int64, notification Localized) error {
+	if localized, ok := bot.(LocalizedDirectDispatcher); ok {
+		return localized.SendToLocalized(chatID, notification)
+	}
+	return bot.SendTo(chatID, []string{notification(i18n.Default, nil)})
+}
+
+// editLocalized edits announcements in chat locales if the editor supports them, otherwise in the default locale
+func editLocalized(editor AnnouncementEditor, game *Game, text Localized) error {
+	if localized, ok := editor.(LocalizedAnnouncementEditor); ok {
+		return localized.EditAnnouncementsLocalized(game, text)
+	}
+	return editor.EditAnnouncements(game, text(i18n.Default, nil))
+}
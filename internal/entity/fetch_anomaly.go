@@ -0,0 +1,56 @@
+package entity
+
+import (
+	"gorm.io/gorm"
+)
+
+// AnomalyReason tells which sanity limit of the absent games check tripped
+type AnomalyReason string
+
+const (
+	// AnomalyReasonNoGames means the run parsed no games at all while future games are stored
+	AnomalyReasonNoGames AnomalyReason = "no_games"
+	// AnomalyReasonAbsentCount means more stored games are absent than [AbsenceGuard.MaxAbsent]
+	AnomalyReasonAbsentCount AnomalyReason = "absent_count"
+	// AnomalyReasonAbsentPercent means larger share of stored games is absent than [AbsenceGuard.MaxAbsentPercent]
+	AnomalyReasonAbsentPercent AnomalyReason = "absent_percent"
+)
+
+// AbsenceGuard are sanity limits of cancelling stored games absent from the fetch run. Partial Rolecon outage
+// or changed page layout looks like mass cancellation, so such runs cancel nothing
+type AbsenceGuard struct {
+	// MaxAbsent is the number of absent games above which the run is anomalous; zero disables the limit
+	MaxAbsent int
+	// MaxAbsentPercent is the share of absent stored games above which the run is anomalous; zero disables the limit
+	MaxAbsentPercent int
+	// Runs is the number of consecutive runs the game must be absent in before it is cancelled
+	Runs int
+}
+
+// Check returns the reason the run is anomalous or empty string if absent games may be cancelled.
+// Parsed is the number of games of the run, stored is the number of stored future games, absent is how many of them
+// the run does not have
+func (g AbsenceGuard) Check(parsed, stored, absent int) AnomalyReason {
+	switch {
+	case stored == 0 || absent == 0:
+		return ""
+	case parsed == 0:
+		return AnomalyReasonNoGames
+	case g.MaxAbsent > 0 && absent > g.MaxAbsent:
+		return AnomalyReasonAbsentCount
+	case g.MaxAbsentPercent > 0 && absent*100 > g.MaxAbsentPercent*stored:
+		return AnomalyReasonAbsentPercent
+	}
+	return ""
+}
+
+// FetchAnomaly records the fetch run which tripped [AbsenceGuard]; cancellations of the run were skipped
+type FetchAnomaly struct {
+	gorm.Model
+	Reason      AnomalyReason `json:"reason" gorm:"size:30;not null"`
+	ParsedCount int           `json:"parsed_count" gorm:"default:0;not null"`
+	StoredCount int           `json:"stored_count" gorm:"default:0;not null"`
+	AbsentCount int           `json:"absent_count" gorm:"default:0;not null"`
+	// Alerted is true if the admin chat was told about the run
+	Alerted bool `json:"alerted" gorm:"default:false;not null"`
+}
@@ -0,0 +1,30 @@
+package entity
+
+import "testing"
+
+func TestAbsenceGuard_Check(t *testing.T) {
+	guard := AbsenceGuard{MaxAbsent: 10, MaxAbsentPercent: 30, Runs: 2}
+	tests := []struct {
+		name                   string
+		guard                  AbsenceGuard
+		parsed, stored, absent int
+		want                   AnomalyReason
+	}{
+		{"nothing absent", guard, 50, 50, 0, ""},
+		{"nothing stored", guard, 0, 0, 0, ""},
+		{"no games parsed", guard, 0, 5, 5, AnomalyReasonNoGames},
+		{"few cancelled", guard, 48, 50, 2, ""},
+		{"too many absent", guard, 39, 50, 11, AnomalyReasonAbsentCount},
+		{"large share absent", guard, 7, 10, 4, AnomalyReasonAbsentPercent},
+		{"share at the limit", guard, 7, 10, 3, ""},
+		{"limits disabled", AbsenceGuard{}, 1, 100, 99, ""},
+		{"zero games even with limits disabled", AbsenceGuard{}, 0, 100, 100, AnomalyReasonNoGames},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.guard.Check(tt.parsed, tt.stored, tt.absent); got != tt.want {
+				t.Errorf("Check(%d, %d, %d) = %q, want %q", tt.parsed, tt.stored, tt.absent, got, tt.want)
+			}
+		})
+	}
+}
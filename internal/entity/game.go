@@ -2,17 +2,27 @@ package entity
 
 import (
 	"fmt"
-	"gorm.io/gorm"
+	"strings"
 	"time"
+
+	"github.com/kettari/location-bot/internal/i18n"
+	"github.com/kettari/location-bot/internal/templates"
+	"gorm.io/gorm"
 )
 
 type CalendarEventType string
 
+const (
+	cardDescriptionLimit = 1500
+	cardNotesLimit       = 500
+)
+
 type Game struct {
 	gorm.Model
 	ExternalID  string    `json:"id" gorm:"unique;not null"`
 	Joinable    bool      `json:"joinable" gorm:"default:false;not null"`
 	URL         string    `json:"url" gorm:"size:1024"`
+	PageURL     string    `json:"page_url" gorm:"size:1024;index"` // event page the game was parsed from
 	Title       string    `json:"title" gorm:"size:1024"`
 	Date        time.Time `json:"date" gorm:"index"`
 	Setting     string    `json:"setting" gorm:"size:100"`
@@ -24,22 +34,18 @@ type Game struct {
 	Notes       string    `json:"notes"`
 	SeatsTotal  int       `json:"seats_total" gorm:"default:0;not null"`
 	SeatsFree   int       `json:"seats_free" gorm:"default:0;not null"`
+	EventClass  string    `json:"event_class" gorm:"size:100"` // space separated classes of the calendar event
 	Slot        int       `json:"-" gorm:"-:all"`
+	// AbsentRuns counts consecutive fetch runs the stored game was absent from, see [AbsenceGuard.Runs]
+	AbsentRuns int `json:"absent_runs" gorm:"default:0;not null"`
+	// BulkRelease is set for the new game released together with many others, e.g. festival program;
+	// it is announced in the grouped message instead of its own
+	BulkRelease bool `json:"-" gorm:"-:all"`
 
 	// Observers
 	observerList []*Observer
 }
 
-var dow = map[string]string{
-	"Mon": "ПОНЕДЕЛЬНИК",
-	"Tue": "ВТОРНИК",
-	"Wed": "СРЕДА",
-	"Thu": "ЧЕТВЕРГ",
-	"Fri": "ПЯТНИЦА",
-	"Sat": "СУББОТА",
-	"Sun": "ВОСКРЕСЕНЬЕ",
-}
-
 func (g *Game) EqualDate(game *Game) bool {
 	return g.Date.In(time.UTC).String() == game.Date.In(time.UTC).String()
 }
@@ -61,72 +67,76 @@ func (g *Game) BecomeJoinable(game *Game) bool {
 	return g.Date.After(time.Now()) && g.Joinable && g.SeatsFree > 0 && !game.Joinable
 }
 
+// SeatsChanged returns true if game is in the future and its seats or joinable state differ from the stored game
+func (g *Game) SeatsChanged(game *Game) bool {
+	return g.Date.After(time.Now()) &&
+		(g.SeatsFree != game.SeatsFree || g.SeatsTotal != game.SeatsTotal || g.Joinable != game.Joinable)
+}
+
 // WasJoinable returns true if game was joinable. Used for cancellation checks
 func (g *Game) WasJoinable() bool {
 	return g.Date.After(time.Now()) && g.SeatsTotal > 0
 }
 
-func (g *Game) FormatNew() string {
-	moscow, err := time.LoadLocation("Europe/Moscow")
-	if err != nil {
-		panic(err)
-	}
+// FormatNew returns announcement of the new game in the locale with dates in the time zone, nil means the default one
+func (g *Game) FormatNew(locale i18n.Locale, location *time.Location) string {
+	return templates.RenderIn(locale, location, templates.GameNew, g)
+}
 
-	result := fmt.Sprintf("<b>%s</b> (%s, %s)",
-		dow[g.Date.In(moscow).Format("Mon")],
-		g.Date.In(moscow).Format("02.01"),
-		g.Date.In(moscow).Format("15:04"))
+// FormatFreeSeatsAdded returns notification of the game which got free seats
+func (g *Game) FormatFreeSeatsAdded(locale i18n.Locale, location *time.Location) string {
+	return templates.RenderIn(locale, location, templates.GameFreeSeats, g)
+}
 
-	result += fmt.Sprintf("\n%d/%d <a href=\"%s\">%s</a> [%s; %s]",
-		g.SeatsFree,
-		g.SeatsTotal,
-		g.URL,
-		g.Title,
-		g.System,
-		g.Setting)
+// FormatCancelled returns notification of the cancelled game
+func (g *Game) FormatCancelled(locale i18n.Locale, location *time.Location) string {
+	return templates.RenderIn(locale, location, templates.GameCancelled, g)
+}
 
-	return result
+// FormatAnnouncement returns channel announcement reflecting the current state of the game:
+// seats count, "full" mark or strike-through when cancelled. Posted announcements are edited with it
+func (g *Game) FormatAnnouncement(locale i18n.Locale, location *time.Location) string {
+	return templates.RenderIn(locale, location, templates.GameAnnouncement, g)
 }
 
-func (g *Game) FormatFreeSeatsAdded() string {
-	moscow, err := time.LoadLocation("Europe/Moscow")
-	if err != nil {
-		panic(err)
-	}
+// FormatCard returns detailed game card with master, genre and shortened description
+func (g *Game) FormatCard(locale i18n.Locale, location *time.Location) string {
+	return templates.RenderIn(locale, location, templates.GameCard, g)
+}
 
-	result := fmt.Sprintf("Освободилось место:\n\n<b>%s</b> (%s, %s)",
-		dow[g.Date.In(moscow).Format("Mon")],
-		g.Date.In(moscow).Format("02.01"),
-		g.Date.In(moscow).Format("15:04"))
+// ShortDescription returns description for the game card cut to the limit with whitespace collapsed
+func (g *Game) ShortDescription() string {
+	return cleanText(g.Description, cardDescriptionLimit)
+}
 
-	result += fmt.Sprintf("\n%d/%d <a href=\"%s\">%s</a> [%s; %s]",
-		g.SeatsFree,
-		g.SeatsTotal,
-		g.URL,
-		g.Title,
-		g.System,
-		g.Setting)
+// ShortNotes returns notes for the game card cut to the limit with whitespace collapsed
+func (g *Game) ShortNotes() string {
+	return cleanText(g.Notes, cardNotesLimit)
+}
 
-	return result
+// CardURL returns Telegram deep link which opens game card in the private chat with the bot
+func (g *Game) CardURL(botUsername string) string {
+	return fmt.Sprintf("https://t.me/%s?start=game_%d", strings.TrimPrefix(botUsername, "@"), g.ID)
 }
 
-func (g *Game) FormatCancelled() string {
-	moscow, err := time.LoadLocation("Europe/Moscow")
-	if err != nil {
-		panic(err)
+// cleanText collapses whitespace and empty lines and cuts text to limit runes on the word boundary
+func cleanText(text string, limit int) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); len(line) > 0 {
+			lines = append(lines, line)
+		}
 	}
-
-	result := fmt.Sprintf("Игра отменена:\n\n<b>%s</b> (%s, %s)",
-		dow[g.Date.In(moscow).Format("Mon")],
-		g.Date.In(moscow).Format("02.01"),
-		g.Date.In(moscow).Format("15:04"))
-
-	result += fmt.Sprintf("\n%s [%s; %s]",
-		g.Title,
-		g.System,
-		g.Setting)
-
-	return result
+	text = strings.Join(lines, "\n")
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	cut := string(runes[:limit])
+	if space := strings.LastIndexAny(cut, " \n"); space > 0 {
+		cut = cut[:space]
+	}
+	return strings.TrimRight(cut, ".,;:—- ") + "…"
 }
 
 func (g *Game) Register(observer Observer) {
@@ -150,3 +160,11 @@ func (g *Game) OnBecomeJoinable() {
 func (g *Game) OnCancelled() {
 	g.notifyAll(SubjectTypeCancelled)
 }
+
+func (g *Game) OnFreeSeatsAdded() {
+	g.notifyAll(SubjectTypeFreeSeatsAdded)
+}
+
+func (g *Game) OnSeatsChanged() {
+	g.notifyAll(SubjectTypeSeatsChanged)
+}
@@ -0,0 +1,121 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kettari/location-bot/internal/i18n"
+)
+
+func TestCleanText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  string
+	}{
+		{name: "short text", text: "Приключение", limit: 100, want: "Приключение"},
+		{name: "collapse whitespace", text: "  Эпическое \t приключение  ", limit: 100, want: "Эпическое приключение"},
+		{name: "drop empty lines", text: "Первый абзац\n\n\n  Второй абзац  \n", limit: 100, want: "Первый абзац\nВторой абзац"},
+		{name: "cut on word boundary", text: "Эпическое приключение в мире драконов", limit: 25, want: "Эпическое приключение в…"},
+		{name: "empty text", text: " \n ", limit: 100, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cleanText(tt.text, tt.limit); got != tt.want {
+				t.Errorf("cleanText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGame_FormatCard(t *testing.T) {
+	game := &Game{
+		Title:       "Клинки во тьме <18+>",
+		Date:        time.Date(2025, 11, 1, 16, 0, 0, 0, time.UTC),
+		System:      "Blades in the Dark",
+		Genre:       "Нуар",
+		MasterName:  "Иван",
+		MasterLink:  "https://rolecon.ru/user/1",
+		Description: strings.Repeat("слово ", 500),
+		SeatsTotal:  5,
+		SeatsFree:   2,
+	}
+	card := game.FormatCard(i18n.Russian, nil)
+
+	for _, want := range []string{
+		"<b>Клинки во тьме &lt;18+&gt;</b>",
+		"суббота, 01.11, 19:00",
+		"Свободно мест: 2/5",
+		`Мастер: <a href="https://rolecon.ru/user/1">Иван</a>`,
+		"Система: Blades in the Dark",
+		"Жанр: Нуар",
+	} {
+		if !strings.Contains(card, want) {
+			t.Errorf("FormatCard() does not contain %q:\n%s", want, card)
+		}
+	}
+	if len([]rune(card)) > 2000 {
+		t.Errorf("FormatCard() length = %d runes, description is not limited", len([]rune(card)))
+	}
+}
+
+func TestGame_CardURL(t *testing.T) {
+	game := &Game{}
+	game.ID = 42
+	if got := game.CardURL("@location_bot"); got != "https://t.me/location_bot?start=game_42" {
+		t.Errorf("CardURL() = %s", got)
+	}
+}
+
+func TestGame_FormatAnnouncement(t *testing.T) {
+	date := time.Date(2025, 11, 1, 16, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		game     Game
+		contains string
+		struck   bool
+	}{
+		{name: "open", game: Game{Joinable: true, SeatsTotal: 5, SeatsFree: 2}, contains: "2/5"},
+		{name: "full", game: Game{Joinable: false, SeatsTotal: 5, SeatsFree: 0}, contains: "🔒 Мест нет"},
+		{name: "cancelled", game: Game{Joinable: false, SeatsTotal: 5, SeatsFree: 2}, contains: "❌ Игра отменена", struck: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.game.Title = "Подземелье"
+			tt.game.Date = date
+			got := tt.game.FormatAnnouncement(i18n.Russian, nil)
+			if !strings.Contains(got, tt.contains) || !strings.Contains(got, "Подземелье") {
+				t.Errorf("FormatAnnouncement() = %q, want it to contain %q", got, tt.contains)
+			}
+			if strings.HasPrefix(got, "<s>") != tt.struck {
+				t.Errorf("FormatAnnouncement() = %q, strike-through %v", got, tt.struck)
+			}
+		})
+	}
+}
+
+func TestGame_SeatsChanged(t *testing.T) {
+	stored := Game{Date: time.Now().Add(24 * time.Hour), Joinable: true, SeatsTotal: 5, SeatsFree: 2}
+
+	same := stored
+	if same.SeatsChanged(&stored) {
+		t.Error("SeatsChanged() = true for unchanged game")
+	}
+	taken := stored
+	taken.SeatsFree = 1
+	if !taken.SeatsChanged(&stored) {
+		t.Error("SeatsChanged() = false after seat was taken")
+	}
+	full := stored
+	full.SeatsFree, full.Joinable = 0, false
+	if !full.SeatsChanged(&stored) {
+		t.Error("SeatsChanged() = false after game became full")
+	}
+	past := taken
+	past.Date = time.Now().Add(-time.Hour)
+	if past.SeatsChanged(&stored) {
+		t.Error("SeatsChanged() = true for past game")
+	}
+}
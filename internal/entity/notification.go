@@ -0,0 +1,126 @@
+package entity
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kettari/location-bot/internal/i18n"
+	"gorm.io/gorm"
+)
+
+type NotificationStatus string
+
+const (
+	NotificationStatusPending   NotificationStatus = "pending"
+	NotificationStatusDelivered NotificationStatus = "delivered"
+	NotificationStatusDead      NotificationStatus = "dead"
+)
+
+const (
+	// NotificationMaxAttempts is the number of delivery attempts before the notification is dead-lettered
+	NotificationMaxAttempts  = 8
+	notificationBaseBackoff  = 30 * time.Second
+	notificationMaxBackoff   = time.Hour
+	notificationErrorMaxSize = 1024
+)
+
+// Notification is the outbox record of a message caused by a game event.
+// It is written in the same transaction as the game and delivered later by the notifications:deliver worker
+type Notification struct {
+	gorm.Model
+	// IdempotencyKey identifies the game state change, subject and destination; the same change is never enqueued twice
+	IdempotencyKey string      `json:"idempotency_key" gorm:"size:64;uniqueIndex;not null"`
+	GameID         uint        `json:"game_id" gorm:"index"`
+	Subject        SubjectType `json:"subject" gorm:"size:30"`
+	// ChatID is the private chat of the user; zero means configured notification chats
+	ChatID int64 `json:"chat_id" gorm:"default:0;not null"`
+	// Destination overrides configured notification chats for the routed notification, see [Routes]
+	Destination string `json:"destination" gorm:"size:1024;default:'';not null"`
+	// Locale of the text; broadcast notification goes only to chats of the locale, empty one goes to all chats
+	Locale i18n.Locale `json:"locale" gorm:"size:8;default:'';not null"`
+	Text   string      `json:"text" gorm:"not null"`
+	// Edit replaces text of the game announcements in notification chats instead of sending new message
+	Edit          bool               `json:"edit" gorm:"default:false;not null"`
+	Status        NotificationStatus `json:"status" gorm:"size:20;index:idx_notification_due;default:pending;not null"`
+	Attempts      int                `json:"attempts" gorm:"default:0;not null"`
+	NextAttemptAt time.Time          `json:"next_attempt_at" gorm:"index:idx_notification_due"`
+	LastError     string             `json:"last_error" gorm:"size:1024"`
+	DeliveredAt   *time.Time         `json:"delivered_at"`
+	// DeliveredTo lists comma separated channels which already got the broadcast notification, e.g. "telegram,slack:1a2b3c4d",
+	// so retry after partial failure does not repeat the message in them
+	DeliveredTo string `json:"delivered_to" gorm:"size:1024;default:'';not null"`
+	// Digest marks the private notification held for the daily digest of the user; due digest notifications
+	// of the chat are sent together as one message
+	Digest bool `json:"digest" gorm:"default:false;not null"`
+}
+
+// DeliveredChannels returns channels which already got the notification
+func (n *Notification) DeliveredChannels() []string {
+	if len(n.DeliveredTo) == 0 {
+		return nil
+	}
+	return strings.Split(n.DeliveredTo, ",")
+}
+
+// AddDeliveredChannels records channels which got the notification
+func (n *Notification) AddDeliveredChannels(channels ...string) {
+	delivered := n.DeliveredChannels()
+	for _, channel := range channels {
+		if !slices.Contains(delivered, channel) {
+			delivered = append(delivered, channel)
+		}
+	}
+	n.DeliveredTo = strings.Join(delivered, ",")
+}
+
+// Reaches returns true if the broadcast notification goes to the recipient "chat_id,thread_id" in the locale:
+// the recipient is in the destination of the notification, defaultDestination if it is not routed,
+// and the notification is in the locale of the chat or in any locale
+func (n *Notification) Reaches(recipient, defaultDestination string, locale i18n.Locale) bool {
+	if n.ChatID != 0 || (len(n.Locale) > 0 && n.Locale != locale) {
+		return false
+	}
+	destination := n.Destination
+	if len(destination) == 0 {
+		destination = defaultDestination
+	}
+	recipient = strings.TrimSpace(recipient)
+	for _, pair := range strings.Split(destination, ";") {
+		if strings.TrimSpace(pair) == recipient {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivered marks notification as successfully sent
+func (n *Notification) Delivered(now time.Time) {
+	n.Status = NotificationStatusDelivered
+	n.DeliveredAt = &now
+	n.LastError = ""
+}
+
+// Failed records failed delivery attempt and schedules the next one with exponential backoff.
+// Permanent errors and exhausted attempts dead-letter the notification; retryAfter postpones the next attempt
+// if the server asked to wait longer than the backoff
+func (n *Notification) Failed(now time.Time, err error, permanent bool, retryAfter time.Duration) {
+	n.LastError = err.Error()
+	if len(n.LastError) > notificationErrorMaxSize {
+		n.LastError = n.LastError[:notificationErrorMaxSize]
+	}
+	if permanent || n.Attempts >= NotificationMaxAttempts {
+		n.Status = NotificationStatusDead
+		return
+	}
+	n.NextAttemptAt = now.Add(max(NotificationBackoff(n.Attempts), retryAfter))
+}
+
+// NotificationBackoff returns delay after the failed attempt: 30s, 1m, 2m and so on up to an hour
+func NotificationBackoff(attempt int) time.Duration {
+	backoff := notificationBaseBackoff
+	for k := 1; k < attempt && backoff < notificationMaxBackoff; k++ {
+		backoff *= 2
+	}
+	return min(backoff, notificationMaxBackoff)
+}
@@ -0,0 +1,117 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kettari/location-bot/internal/i18n"
+)
+
+func TestNotificationBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 1, want: 30 * time.Second},
+		{attempt: 2, want: time.Minute},
+		{attempt: 3, want: 2 * time.Minute},
+		{attempt: 7, want: 32 * time.Minute},
+		{attempt: 20, want: time.Hour},
+	}
+	for _, tt := range tests {
+		if got := NotificationBackoff(tt.attempt); got != tt.want {
+			t.Errorf("NotificationBackoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestNotification_Failed(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	sendErr := errors.New("telegram: internal server error (500)")
+
+	t.Run("retry with backoff", func(t *testing.T) {
+		n := Notification{Status: NotificationStatusPending, Attempts: 2}
+		n.Failed(now, sendErr, false, 0)
+		if n.Status != NotificationStatusPending {
+			t.Errorf("Status = %v, want pending", n.Status)
+		}
+		if want := now.Add(time.Minute); !n.NextAttemptAt.Equal(want) {
+			t.Errorf("NextAttemptAt = %v, want %v", n.NextAttemptAt, want)
+		}
+		if n.LastError != sendErr.Error() {
+			t.Errorf("LastError = %q", n.LastError)
+		}
+	})
+
+	t.Run("retry after flood wait", func(t *testing.T) {
+		n := Notification{Status: NotificationStatusPending, Attempts: 1}
+		n.Failed(now, sendErr, false, 5*time.Minute)
+		if want := now.Add(5 * time.Minute); !n.NextAttemptAt.Equal(want) {
+			t.Errorf("NextAttemptAt = %v, want %v", n.NextAttemptAt, want)
+		}
+	})
+
+	t.Run("permanent error", func(t *testing.T) {
+		n := Notification{Status: NotificationStatusPending, Attempts: 1}
+		n.Failed(now, sendErr, true, 0)
+		if n.Status != NotificationStatusDead {
+			t.Errorf("Status = %v, want dead", n.Status)
+		}
+	})
+
+	t.Run("attempts exhausted", func(t *testing.T) {
+		n := Notification{Status: NotificationStatusPending, Attempts: NotificationMaxAttempts}
+		n.Failed(now, sendErr, false, 0)
+		if n.Status != NotificationStatusDead {
+			t.Errorf("Status = %v, want dead", n.Status)
+		}
+	})
+}
+
+func TestNotification_Delivered(t *testing.T) {
+	now := time.Now()
+	n := Notification{Status: NotificationStatusPending, LastError: "timeout"}
+	n.Delivered(now)
+	if n.Status != NotificationStatusDelivered || n.DeliveredAt == nil || !n.DeliveredAt.Equal(now) || n.LastError != "" {
+		t.Errorf("unexpected delivered notification: %+v", n)
+	}
+}
+
+func TestNotification_AddDeliveredChannels(t *testing.T) {
+	n := Notification{}
+	if got := n.DeliveredChannels(); len(got) != 0 {
+		t.Errorf("DeliveredChannels() = %v, want empty", got)
+	}
+	n.AddDeliveredChannels("telegram")
+	n.AddDeliveredChannels("slack:1a2b3c4d", "telegram")
+	if n.DeliveredTo != "telegram,slack:1a2b3c4d" {
+		t.Errorf("DeliveredTo = %q", n.DeliveredTo)
+	}
+}
+
+func TestNotification_Reaches(t *testing.T) {
+	const defaultDestination = "-100,0;-200,5"
+	tests := []struct {
+		name         string
+		notification Notification
+		recipient    string
+		locale       i18n.Locale
+		want         bool
+	}{
+		{"default chats", Notification{}, "-200,5", i18n.Russian, true},
+		{"other chat", Notification{}, "-300,0", i18n.Russian, false},
+		{"routed elsewhere", Notification{Destination: "-300,0"}, "-100,0", i18n.Russian, false},
+		{"routed here", Notification{Destination: "-300,0"}, "-300,0", i18n.Russian, true},
+		{"chat locale", Notification{Locale: i18n.English}, "-100,0", i18n.English, true},
+		{"other locale", Notification{Locale: i18n.English}, "-100,0", i18n.Russian, false},
+		{"private", Notification{ChatID: 42}, "-100,0", i18n.Russian, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.notification.Reaches(tt.recipient, defaultDestination, tt.locale); got != tt.want {
+				t.Errorf("Reaches(%q) = %v, want %v", tt.recipient, got, tt.want)
+			}
+		})
+	}
+}
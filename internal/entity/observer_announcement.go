@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"log/slog"
+)
+
+type AnnouncementGame struct {
+	editor AnnouncementEditor
+}
+
+// AnnouncementGameObserver edits posted announcements of the game when its seats change or it is cancelled.
+// The notifications:deliver worker skips edits of games without posted announcements
+func AnnouncementGameObserver(editor AnnouncementEditor) *AnnouncementGame {
+	return &AnnouncementGame{
+		editor: editor,
+	}
+}
+
+func (g *AnnouncementGame) Update(game *Game, subject SubjectType) {
+	if subject != SubjectTypeSeatsChanged && subject != SubjectTypeCancelled {
+		return
+	}
+	slog.Info("game announcement update event fired", "game_id", game.ExternalID, "subject", subject)
+	if err := editLocalized(g.editor, game, game.FormatAnnouncement); err != nil {
+		slog.Error("announcement update event error", "error", err)
+	}
+}
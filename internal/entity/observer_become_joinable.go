@@ -8,22 +8,17 @@ type BecomeJoinableGame struct {
 	bot MessageDispatcher
 }
 
-var becomeJoinableGame *BecomeJoinableGame
-
+// BecomeJoinableGameObserver is created for every fetch run: the dispatcher may be the outbox recorder of the run
 func BecomeJoinableGameObserver(bot MessageDispatcher) *BecomeJoinableGame {
-	if becomeJoinableGame == nil {
-		becomeJoinableGame = &BecomeJoinableGame{
-			bot: bot,
-		}
+	return &BecomeJoinableGame{
+		bot: bot,
 	}
-	return becomeJoinableGame
 }
 
 func (g *BecomeJoinableGame) Update(game *Game, subject SubjectType) {
 	if subject == SubjectTypeBecomeJoinable {
 		slog.Info("game become joinable event fired", "game_id", game.ExternalID)
-		notification := game.FormatFreeSeatsAdded()
-		if err := g.bot.Send([]string{notification}); err != nil {
+		if err := sendLocalized(g.bot, game.FormatFreeSeatsAdded); err != nil {
 			slog.Error("joinable game event error", "error", err)
 		}
 	}
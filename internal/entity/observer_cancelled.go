@@ -8,22 +8,17 @@ type CancelledGame struct {
 	bot MessageDispatcher
 }
 
-var cancelledGame *CancelledGame
-
+// CancelledGameObserver is created for every fetch run: the dispatcher may be the outbox recorder of the run
 func CancelledGameObserver(bot MessageDispatcher) *CancelledGame {
-	if cancelledGame == nil {
-		cancelledGame = &CancelledGame{
-			bot: bot,
-		}
+	return &CancelledGame{
+		bot: bot,
 	}
-	return cancelledGame
 }
 
 func (g *CancelledGame) Update(game *Game, subject SubjectType) {
 	if subject == SubjectTypeCancelled {
 		slog.Info("cancelled game event fired", "game_id", game.ExternalID)
-		notification := game.FormatCancelled()
-		if err := g.bot.Send([]string{notification}); err != nil {
+		if err := sendLocalized(g.bot, game.FormatCancelled); err != nil {
 			slog.Error("cancelled game event error", "error", err)
 		}
 	}
@@ -8,22 +8,21 @@ type NewGame struct {
 	bot MessageDispatcher
 }
 
-var newGame *NewGame
-
+// NewGameObserver is created for every fetch run: the dispatcher may be the outbox recorder of the run
 func NewGameObserver(bot MessageDispatcher) *NewGame {
-	if newGame == nil {
-		newGame = &NewGame{
-			bot: bot,
-		}
+	return &NewGame{
+		bot: bot,
 	}
-	return newGame
 }
 
 func (g *NewGame) Update(game *Game, subject SubjectType) {
 	if subject == SubjectTypeNew {
+		if game.BulkRelease {
+			slog.Debug("new game is announced in the bulk release", "game_id", game.ExternalID)
+			return
+		}
 		slog.Info("new game event fired", "game_id", game.ExternalID)
-		notification := game.FormatNew()
-		if err := g.bot.Send([]string{notification}); err != nil {
+		if err := sendLocalized(g.bot, game.FormatNew); err != nil {
 			slog.Error("new game event error", "error", err)
 		}
 	}
@@ -0,0 +1,57 @@
+package entity
+
+import (
+	"log/slog"
+	"time"
+)
+
+type SubscribersGame struct {
+	bot           DirectMessageDispatcher
+	subscriptions []Subscription
+	users         map[int64]UserSettings
+}
+
+// SubscribersGameObserver notifies users privately about new and re-opened games matching their subscriptions.
+// Unlike channel observers it is not a singleton: subscriptions are loaded for every fetch run.
+// Weekdays and time windows are matched in the time zone of the user settings, if any
+func SubscribersGameObserver(bot DirectMessageDispatcher, subscriptions []Subscription, users map[int64]UserSettings) *SubscribersGame {
+	return &SubscribersGame{
+		bot:           bot,
+		subscriptions: subscriptions,
+		users:         users,
+	}
+}
+
+func (g *SubscribersGame) Update(game *Game, subject SubjectType) {
+	var notification Localized
+	switch subject {
+	case SubjectTypeNew:
+		notification = game.FormatNew
+	case SubjectTypeBecomeJoinable:
+		notification = game.FormatFreeSeatsAdded
+	default:
+		return
+	}
+
+	// User may have several matching subscriptions, notify only once
+	notified := make(map[int64]bool)
+	for k := range g.subscriptions {
+		subscription := &g.subscriptions[k]
+		if notified[subscription.TelegramID] || !subscription.Matches(game, g.location(subscription.TelegramID)) {
+			continue
+		}
+		notified[subscription.TelegramID] = true
+		slog.Info("subscription matched", "game_id", game.ExternalID, "subscription_id", subscription.ID, "subject", subject)
+		if err := sendToLocalized(g.bot, subscription.TelegramID, notification); err != nil {
+			slog.Error("subscription notification error", "subscription_id", subscription.ID, "error", err)
+		}
+	}
+}
+
+// location returns the time zone of the user, nil if the user has no settings
+func (g *SubscribersGame) location(telegramID int64) *time.Location {
+	if settings, ok := g.users[telegramID]; ok {
+		return settings.Location()
+	}
+	return nil
+}
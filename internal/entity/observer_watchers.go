@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"log/slog"
+)
+
+type WatchersGame struct {
+	bot      DirectMessageDispatcher
+	watchers map[uint][]int64
+}
+
+// WatchersGameObserver notifies users privately when a game they watch gets free seats.
+// Watchers map game ID to Telegram IDs and is loaded for every fetch run
+func WatchersGameObserver(bot DirectMessageDispatcher, watchers map[uint][]int64) *WatchersGame {
+	return &WatchersGame{
+		bot:      bot,
+		watchers: watchers,
+	}
+}
+
+func (g *WatchersGame) Update(game *Game, subject SubjectType) {
+	if subject != SubjectTypeFreeSeatsAdded {
+		return
+	}
+	telegramIDs := g.watchers[game.ID]
+	if len(telegramIDs) == 0 {
+		return
+	}
+	slog.Info("watched game got free seats", "game_id", game.ExternalID, "watchers_count", len(telegramIDs))
+	for _, telegramID := range telegramIDs {
+		if err := sendToLocalized(g.bot, telegramID, game.FormatFreeSeatsAdded); err != nil {
+			slog.Error("watched game notification error", "game_id", game.ExternalID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package entity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/kettari/location-bot/internal/i18n"
+)
+
+// OutboxRecorder is the dispatcher which records notifications to the outbox instead of sending them.
+// Observers registered with the recorder do not lose notifications if Telegram is unavailable:
+// the caller saves recorded notifications in the same transaction as the game
+type OutboxRecorder struct {
+	game          *Game
+	subject       SubjectType
+	version       time.Time
+	routes        Routes
+	locales       Locales
+	users         map[int64]UserSettings
+	notifications []Notification
+}
+
+// Locales are languages of notification recipients
+type Locales struct {
+	// Default is the locale of notification chats without own locale, of other transports and of unknown users
+	Default i18n.Locale
+	// Chats lists other locales of notification chats
+	Chats []i18n.Locale
+}
+
+// chats returns distinct locales of notification chats, the default one first
+func (l Locales) chats() []i18n.Locale {
+	result := []i18n.Locale{l.fallback()}
+	for _, locale := range l.Chats {
+		if !slices.Contains(result, locale) {
+			result = append(result, locale)
+		}
+	}
+	return result
+}
+
+func (l Locales) fallback() i18n.Locale {
+	if len(l.Default) == 0 {
+		return i18n.Default
+	}
+	return l.Default
+}
+
+func NewOutboxRecorder() *OutboxRecorder {
+	return &OutboxRecorder{}
+}
+
+// UseRoutes sends notifications of matching games to the route destinations instead of configured notification chats
+func (r *OutboxRecorder) UseRoutes(routes Routes) {
+	r.routes = routes
+}
+
+// UseLocales records localized notifications to notification chats once per locale of the chats
+func (r *OutboxRecorder) UseLocales(locales Locales) {
+	r.locales = locales
+}
+
+// UseUsers records localized private notifications in the locale and time zone of the user, held back
+// during quiet hours and until the daily digest if the user chose it. Users without settings get the default locale
+func (r *OutboxRecorder) UseUsers(users map[int64]UserSettings) {
+	r.users = users
+}
+
+// Begin attributes notifications sent until the next call to the game event.
+// Version is the update time of the game state the event was detected against
+func (r *OutboxRecorder) Begin(game *Game, subject SubjectType, version time.Time) {
+	r.game = game
+	r.subject = subject
+	r.version = version
+}
+
+// Send implements [MessageDispatcher], the notification goes to configured notification chats
+func (r *OutboxRecorder) Send(notification []string) error {
+	return r.SendTo(0, notification)
+}
+
+// SendTo implements [DirectMessageDispatcher]
+func (r *OutboxRecorder) SendTo(chatID int64, notification []string) error {
+	if r.game == nil {
+		return fmt.Errorf("notification outside of game event")
+	}
+	for k, text := range notification {
+		r.record(chatID, k, text, false, "")
+	}
+	return nil
+}
+
+// SendToRecipients records the notification sent in the locale to the recipients "chat_id1,thread_id1;chat_id2,thread_id2"
+// instead of configured notification chats or the route destination, e.g. by the report of unnotified games
+func (r *OutboxRecorder) SendToRecipients(recipients string, locale i18n.Locale, notification []string) error {
+	if r.game == nil {
+		return fmt.Errorf("notification outside of game event")
+	}
+	for k, text := range notification {
+		r.recordTo(recipients, 0, k, text, false, locale)
+	}
+	return nil
+}
+
+// SendLocalized implements [LocalizedDispatcher], the notification is recorded once per locale of notification chats
+func (r *OutboxRecorder) SendLocalized(notification Localized) error {
+	if r.game == nil {
+		return fmt.Errorf("notification outside of game event")
+	}
+	for _, locale := range r.locales.chats() {
+		r.record(0, 0, notification(locale, nil), false, locale)
+	}
+	return nil
+}
+
+// SendPartsLocalized implements [LocalizedPartsDispatcher], every part is recorded once per locale of notification chats
+func (r *OutboxRecorder) SendPartsLocalized(notification LocalizedParts) error {
+	if r.game == nil {
+		return fmt.Errorf("notification outside of game event")
+	}
+	for _, locale := range r.locales.chats() {
+		for k, text := range notification(locale) {
+			r.record(0, k, text, false, locale)
+		}
+	}
+	return nil
+}
+
+// SendToLocalized implements [LocalizedDirectDispatcher]
+func (r *OutboxRecorder) SendToLocalized(chatID int64, notification Localized) error {
+	if r.game == nil {
+		return fmt.Errorf("notification outside of game event")
+	}
+	settings, ok := r.users[chatID]
+	if !ok {
+		locale := r.locales.fallback()
+		r.record(chatID, 0, notification(locale, nil), false, locale)
+		return nil
+	}
+	locale := settings.EffectiveLocale()
+	r.record(chatID, 0, notification(locale, settings.Location()), false, locale)
+	recorded := &r.notifications[len(r.notifications)-1]
+	recorded.NextAttemptAt = settings.ReleaseAt(recorded.NextAttemptAt)
+	recorded.Digest = settings.Digest
+	return nil
+}
+
+// EditAnnouncements implements [AnnouncementEditor]
+func (r *OutboxRecorder) EditAnnouncements(game *Game, text string) error {
+	if r.game != game {
+		return fmt.Errorf("announcement edit outside of game event")
+	}
+	if r.routed() {
+		return nil
+	}
+	r.record(0, -1, text, true, "")
+	return nil
+}
+
+// EditAnnouncementsLocalized implements [LocalizedAnnouncementEditor], the edit is recorded once per locale
+// of notification chats
+func (r *OutboxRecorder) EditAnnouncementsLocalized(game *Game, text Localized) error {
+	if r.game != game {
+		return fmt.Errorf("announcement edit outside of game event")
+	}
+	if r.routed() {
+		return nil
+	}
+	for _, locale := range r.locales.chats() {
+		r.record(0, -1, text(locale, nil), true, locale)
+	}
+	return nil
+}
+
+// routed returns true if the event is routed to its destination: the message sent there replaces
+// the announcement edit, so e.g. cancellations routed to the admin chat do not show in notification chats
+func (r *OutboxRecorder) routed() bool {
+	return len(r.routes.Destination(r.game, r.subject)) > 0
+}
+
+// record adds the notification; empty locale means the text is sent to chats of any locale
+func (r *OutboxRecorder) record(chatID int64, part int, text string, edit bool, locale i18n.Locale) {
+	destination := ""
+	if chatID == 0 && !edit {
+		destination = r.routes.Destination(r.game, r.subject)
+	}
+	r.recordTo(destination, chatID, part, text, edit, locale)
+}
+
+// recordTo adds the notification to the destination, empty one means configured notification chats.
+// The notification with the key already recorded is skipped
+func (r *OutboxRecorder) recordTo(destination string, chatID int64, part int, text string, edit bool, locale i18n.Locale) {
+	key := r.idempotencyKey(destination, chatID, part, locale)
+	if slices.ContainsFunc(r.notifications, func(n Notification) bool { return n.IdempotencyKey == key }) {
+		return
+	}
+	r.notifications = append(r.notifications, Notification{
+		IdempotencyKey: key,
+		GameID:         r.game.ID,
+		Subject:        r.subject,
+		ChatID:         chatID,
+		Destination:    destination,
+		Locale:         locale,
+		Text:           text,
+		Edit:           edit,
+		Status:         NotificationStatusPending,
+		NextAttemptAt:  time.Now(),
+	})
+}
+
+// Take returns recorded notifications and resets the recorder
+func (r *OutboxRecorder) Take() []Notification {
+	notifications := r.notifications
+	r.notifications = nil
+	r.game = nil
+	return notifications
+}
+
+// idempotencyKey of the notification part; edits have part -1. Private notifications share the key
+// of the game state change regardless of the event: a user who both subscribed to the game and watches it
+// gets one message when the full game frees a seat, although both become joinable and free seats added are fired
+func (r *OutboxRecorder) idempotencyKey(destination string, chatID int64, part int, locale i18n.Locale) string {
+	subject := string(r.subject)
+	if chatID != 0 {
+		subject = "private"
+	}
+	key := fmt.Sprintf("%s|%d|%s|%d|%d", r.game.ExternalID, r.version.UnixNano(), subject, chatID, part)
+	if len(locale) > 0 {
+		key += "|" + string(locale)
+	}
+	if len(destination) > 0 {
+		key += "|" + destination
+	}
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
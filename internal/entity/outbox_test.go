@@ -0,0 +1,265 @@
+package entity
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kettari/location-bot/internal/i18n"
+)
+
+func TestOutboxRecorder(t *testing.T) {
+	game := &Game{ExternalID: "game12345", Title: "Test"}
+	game.ID = 7
+	version := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	recorder := NewOutboxRecorder()
+	if err := recorder.Send([]string{"orphan"}); err == nil {
+		t.Error("expected error for notification outside of game event")
+	}
+
+	recorder.Begin(game, SubjectTypeNew, version)
+	if err := recorder.Send([]string{"new game"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.SendTo(42, []string{"new game for you"}); err != nil {
+		t.Fatal(err)
+	}
+	recorder.Begin(game, SubjectTypeFreeSeatsAdded, version)
+	if err := recorder.SendTo(43, []string{"seats"}); err != nil {
+		t.Fatal(err)
+	}
+
+	notifications := recorder.Take()
+	if len(notifications) != 3 {
+		t.Fatalf("got %d notifications, want 3", len(notifications))
+	}
+	if n := notifications[1]; n.GameID != 7 || n.ChatID != 42 || n.Subject != SubjectTypeNew || n.Status != NotificationStatusPending {
+		t.Errorf("unexpected notification: %+v", n)
+	}
+	keys := make(map[string]bool)
+	for _, n := range notifications {
+		keys[n.IdempotencyKey] = true
+	}
+	if len(keys) != 3 {
+		t.Errorf("idempotency keys are not unique: %v", keys)
+	}
+	if len(recorder.Take()) != 0 {
+		t.Error("Take() did not reset the recorder")
+	}
+
+	// The same state change yields the same keys
+	recorder.Begin(game, SubjectTypeNew, version)
+	_ = recorder.Send([]string{"new game again"})
+	if again := recorder.Take(); again[0].IdempotencyKey != notifications[0].IdempotencyKey {
+		t.Error("idempotency key of the same event changed")
+	}
+
+	// Next state change yields new keys
+	recorder.Begin(game, SubjectTypeNew, version.Add(time.Minute))
+	_ = recorder.Send([]string{"new game later"})
+	if later := recorder.Take(); later[0].IdempotencyKey == notifications[0].IdempotencyKey {
+		t.Error("idempotency key of the next event is the same")
+	}
+
+	// Announcement edits are broadcast notifications flagged as edits
+	recorder.Begin(game, SubjectTypeSeatsChanged, version)
+	if err := recorder.EditAnnouncements(game, "2/5"); err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.EditAnnouncements(&Game{}, "other game"); err == nil {
+		t.Error("expected error for edit of the game outside of its event")
+	}
+	edits := recorder.Take()
+	if len(edits) != 1 || !edits[0].Edit || edits[0].ChatID != 0 || edits[0].Subject != SubjectTypeSeatsChanged {
+		t.Errorf("unexpected edit notifications: %+v", edits)
+	}
+}
+
+func TestOutboxRecorder_PrivateOncePerStateChange(t *testing.T) {
+	game := &Game{ExternalID: "game12345"}
+	version := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	recorder := NewOutboxRecorder()
+
+	// Full game frees a seat: the subscriber observer and the watcher observer notify the same user
+	recorder.Begin(game, SubjectTypeBecomeJoinable, version)
+	_ = recorder.Send([]string{"channel"})
+	_ = recorder.SendToLocalized(42, func(i18n.Locale, *time.Location) string { return "subscription" })
+	recorder.Begin(game, SubjectTypeFreeSeatsAdded, version)
+	_ = recorder.Send([]string{"channel seats"})
+	_ = recorder.SendToLocalized(42, func(i18n.Locale, *time.Location) string { return "watch" })
+	_ = recorder.SendToLocalized(43, func(i18n.Locale, *time.Location) string { return "watch" })
+
+	var got []string
+	for _, n := range recorder.Take() {
+		got = append(got, fmt.Sprintf("%d %s", n.ChatID, n.Text))
+	}
+	if want := []string{"0 channel", "42 subscription", "0 channel seats", "43 watch"}; !slices.Equal(got, want) {
+		t.Errorf("recorded notifications = %q, want %q", got, want)
+	}
+
+	// The next state change notifies the user again
+	recorder.Begin(game, SubjectTypeFreeSeatsAdded, version.Add(time.Minute))
+	_ = recorder.SendToLocalized(42, func(i18n.Locale, *time.Location) string { return "watch" })
+	if len(recorder.Take()) != 1 {
+		t.Error("private notification of the next state change is skipped")
+	}
+}
+
+func TestOutboxRecorder_UseRoutes(t *testing.T) {
+	game := &Game{ExternalID: "game12345", System: "D&D 5e"}
+	recorder := NewOutboxRecorder()
+	recorder.UseRoutes(Routes{{System: []string{"D&D"}, To: "-1002,15"}})
+
+	recorder.Begin(game, SubjectTypeNew, time.Now())
+	_ = recorder.Send([]string{"new game"})
+	_ = recorder.SendTo(42, []string{"new game for you"})
+	recorder.Begin(game, SubjectTypeSeatsChanged, time.Now())
+	_ = recorder.EditAnnouncements(game, "seats")
+
+	notifications := recorder.Take()
+	if notifications[0].Destination != "-1002,15" {
+		t.Errorf("Destination = %q, want route destination", notifications[0].Destination)
+	}
+	if notifications[1].Destination != "" || notifications[2].Destination != "" {
+		t.Error("private messages and edits must not be routed")
+	}
+}
+
+func TestOutboxRecorder_RoutedCancellation(t *testing.T) {
+	game := &Game{ExternalID: "game12345", System: "D&D 5e"}
+	recorder := NewOutboxRecorder()
+	recorder.UseRoutes(Routes{{Subject: []SubjectType{SubjectTypeCancelled}, To: "-1003,0"}})
+
+	recorder.Begin(game, SubjectTypeCancelled, time.Now())
+	_ = recorder.Send([]string{"cancelled"})
+	_ = recorder.EditAnnouncements(game, "<s>announcement</s>")
+	recorder.Begin(game, SubjectTypeSeatsChanged, time.Now())
+	_ = recorder.EditAnnouncements(game, "seats")
+
+	var got []string
+	for _, n := range recorder.Take() {
+		got = append(got, fmt.Sprintf("%s %t %s", n.Subject, n.Edit, n.Destination))
+	}
+	// The cancellation goes only to the route destination, seat edits are not routed
+	if want := []string{"cancelled false -1003,0", "seats_changed true "}; !slices.Equal(got, want) {
+		t.Errorf("recorded notifications = %q, want %q", got, want)
+	}
+}
+
+func TestOutboxRecorder_UseLocales(t *testing.T) {
+	game := &Game{ExternalID: "game12345"}
+	recorder := NewOutboxRecorder()
+	recorder.UseLocales(Locales{
+		Default: i18n.English,
+		Chats:   []i18n.Locale{i18n.Russian, i18n.English},
+	})
+	recorder.UseUsers(map[int64]UserSettings{42: {TelegramID: 42, Locale: i18n.Russian}})
+	text := func(locale i18n.Locale, _ *time.Location) string { return "text " + string(locale) }
+
+	recorder.Begin(game, SubjectTypeNew, time.Now())
+	_ = recorder.SendLocalized(text)
+	_ = recorder.SendToLocalized(42, text)
+	_ = recorder.SendToLocalized(43, text)
+	recorder.Begin(game, SubjectTypeSeatsChanged, time.Now())
+	_ = recorder.EditAnnouncementsLocalized(game, text)
+
+	var got []string
+	keys := make(map[string]bool)
+	for _, n := range recorder.Take() {
+		got = append(got, fmt.Sprintf("%d %t %s %s", n.ChatID, n.Edit, n.Locale, n.Text))
+		keys[n.IdempotencyKey] = true
+	}
+	want := []string{
+		"0 false en text en", "0 false ru text ru",
+		"42 false ru text ru", "43 false en text en",
+		"0 true en text en", "0 true ru text ru",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("recorded notifications = %q, want %q", got, want)
+	}
+	if len(keys) != len(want) {
+		t.Errorf("idempotency keys are not unique: %v", keys)
+	}
+}
+
+func TestOutboxRecorder_SendToRecipients(t *testing.T) {
+	game := &Game{ExternalID: "game12345"}
+	recorder := NewOutboxRecorder()
+	now := time.Now()
+	recorder.Begin(game, SubjectTypeReport, now)
+	_ = recorder.SendToRecipients("-100,0", i18n.Russian, []string{"report"})
+	_ = recorder.SendToRecipients("-200,0", i18n.English, []string{"report"})
+
+	notifications := recorder.Take()
+	if len(notifications) != 2 {
+		t.Fatalf("recorded %d notifications, want 2", len(notifications))
+	}
+	if n := notifications[1]; n.Destination != "-200,0" || n.Locale != i18n.English || n.ChatID != 0 {
+		t.Errorf("recorded notification = %+v, want broadcast to -200,0 in English", n)
+	}
+	if notifications[0].IdempotencyKey == notifications[1].IdempotencyKey {
+		t.Error("idempotency keys of the event in different destinations are the same")
+	}
+}
+
+func TestOutboxRecorder_UseUsers(t *testing.T) {
+	game := &Game{ExternalID: "game12345", Date: time.Date(2025, 1, 4, 15, 0, 0, 0, time.UTC)}
+	recorder := NewOutboxRecorder()
+	recorder.UseUsers(map[int64]UserSettings{
+		42: {TelegramID: 42, Timezone: "Asia/Yekaterinburg"},
+		43: {TelegramID: 43, Digest: true},
+	})
+	text := func(_ i18n.Locale, location *time.Location) string {
+		if location == nil {
+			return "default"
+		}
+		return game.Date.In(location).Format("15:04")
+	}
+
+	before := time.Now()
+	recorder.Begin(game, SubjectTypeNew, before)
+	_ = recorder.SendToLocalized(42, text)
+	_ = recorder.SendToLocalized(43, text)
+	_ = recorder.SendToLocalized(44, text)
+
+	notifications := recorder.Take()
+	if got := notifications[0].Text; got != "20:00" {
+		t.Errorf("text in the user time zone = %q, want %q", got, "20:00")
+	}
+	if notifications[0].Digest || notifications[0].NextAttemptAt.After(time.Now()) {
+		t.Error("instant notification must be due right away")
+	}
+	if !notifications[1].Digest || !notifications[1].NextAttemptAt.After(before) {
+		t.Errorf("digest notification must be held, got digest %t at %v", notifications[1].Digest, notifications[1].NextAttemptAt)
+	}
+	if got := notifications[2].Text; got != "default" {
+		t.Errorf("text for unknown user = %q, want default time zone", got)
+	}
+}
+
+func TestOutboxRecorder_SendPartsLocalized(t *testing.T) {
+	game := &Game{ExternalID: "game12345"}
+	recorder := NewOutboxRecorder()
+	recorder.UseLocales(Locales{Default: i18n.Russian, Chats: []i18n.Locale{i18n.English}})
+
+	recorder.Begin(game, SubjectTypeBulkRelease, time.Now())
+	err := recorder.SendPartsLocalized(func(locale i18n.Locale) []string {
+		if locale == i18n.English {
+			return []string{"en 1"}
+		}
+		return []string{"ru 1", "ru 2"}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, n := range recorder.Take() {
+		got = append(got, fmt.Sprintf("%s %s", n.Locale, n.Text))
+	}
+	if want := []string{"ru ru 1", "ru ru 2", "en en 1"}; !slices.Equal(got, want) {
+		t.Errorf("recorded notifications = %q, want %q", got, want)
+	}
+}
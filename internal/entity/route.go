@@ -0,0 +1,124 @@
+package entity
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kettari/location-bot/internal/templates"
+)
+
+// routedSubjects are events of the notification chats which may be routed
+var routedSubjects = []SubjectType{SubjectTypeNew, SubjectTypeBecomeJoinable, SubjectTypeCancelled}
+
+// Route sends notifications about matching games to its destination instead of configured notification chats.
+// Each filter matches if any of its values does; empty filters match any game
+type Route struct {
+	// System, Genre and Setting are case-insensitive substrings of the game fields
+	System  []string `json:"system"`
+	Genre   []string `json:"genre"`
+	Setting []string `json:"setting"`
+	// Weekday is the day of the game in Moscow time, e.g. "Sat"
+	Weekday []string `json:"weekday"`
+	// Class is the Rolecon calendar event class, see [Game.EventClass]
+	Class   []string      `json:"class"`
+	Subject []SubjectType `json:"subject"`
+	// To is "chat_id1,thread_id1;chat_id2,thread_id2" like the notification chats configuration
+	To string `json:"to"`
+}
+
+// Routes are checked in order, the first matching route wins
+type Routes []Route
+
+// ParseRoutes decodes routes from JSON array and validates them
+func ParseRoutes(data []byte) (Routes, error) {
+	var routes Routes
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&routes); err != nil {
+		return nil, fmt.Errorf("invalid routes JSON: %w", err)
+	}
+	for k := range routes {
+		if err := routes[k].validate(); err != nil {
+			return nil, fmt.Errorf("route %d: %w", k+1, err)
+		}
+	}
+	return routes, nil
+}
+
+// Destination returns chats for the game event; empty string means configured notification chats
+func (r Routes) Destination(game *Game, subject SubjectType) string {
+	if !slices.Contains(routedSubjects, subject) {
+		return ""
+	}
+	for k := range r {
+		if r[k].Matches(game, subject) {
+			return r[k].To
+		}
+	}
+	return ""
+}
+
+// Matches returns true if the game event satisfies all filters of the route. Weekdays are matched
+// in [templates.DefaultTimezone]; the route with weekdays does not match if the time zone cannot be loaded
+func (r *Route) Matches(game *Game, subject SubjectType) bool {
+	weekday := ""
+	if len(r.Weekday) > 0 {
+		location, err := templates.LoadLocation(templates.DefaultTimezone)
+		if err != nil {
+			slog.Error("cannot load time zone, route does not match", "route", r.To, "error", err)
+			return false
+		}
+		weekday = game.Date.In(location).Format("Mon")
+	}
+	classes := strings.Fields(strings.ToLower(game.EventClass))
+
+	return matchesAny(r.System, func(value string) bool { return containsFold(game.System, value) }) &&
+		matchesAny(r.Genre, func(value string) bool { return containsFold(game.Genre, value) }) &&
+		matchesAny(r.Setting, func(value string) bool { return containsFold(game.Setting, value) }) &&
+		matchesAny(r.Weekday, func(value string) bool { return strings.EqualFold(weekday, value) }) &&
+		matchesAny(r.Class, func(value string) bool { return slices.Contains(classes, strings.ToLower(value)) }) &&
+		(len(r.Subject) == 0 || slices.Contains(r.Subject, subject))
+}
+
+func (r *Route) validate() error {
+	for _, weekday := range r.Weekday {
+		if _, ok := parseWeekday(weekday); !ok {
+			return fmt.Errorf("unknown weekday %q, use Mon, Tue, Wed, Thu, Fri, Sat or Sun", weekday)
+		}
+	}
+	for _, subject := range r.Subject {
+		if !slices.Contains(routedSubjects, subject) {
+			return fmt.Errorf("subject %q cannot be routed, use %s, %s or %s",
+				subject, SubjectTypeNew, SubjectTypeBecomeJoinable, SubjectTypeCancelled)
+		}
+	}
+	for _, values := range [][]string{r.System, r.Genre, r.Setting, r.Class} {
+		if slices.Contains(values, "") {
+			return fmt.Errorf("empty filter value")
+		}
+	}
+
+	if len(r.To) == 0 {
+		return fmt.Errorf("destination is empty")
+	}
+	for _, pair := range strings.Split(r.To, ";") {
+		chatID, threadID, found := strings.Cut(pair, ",")
+		if _, err := strconv.ParseInt(chatID, 10, 64); err != nil || !found {
+			return fmt.Errorf("destination %q must be chat_id,thread_id", pair)
+		}
+		if _, err := strconv.Atoi(threadID); err != nil {
+			return fmt.Errorf("destination %q must be chat_id,thread_id", pair)
+		}
+	}
+	return nil
+}
+
+// matchesAny returns true if values are empty or any of them matches
+func matchesAny(values []string, match func(value string) bool) bool {
+	return len(values) == 0 || slices.ContainsFunc(values, match)
+}
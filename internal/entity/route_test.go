@@ -0,0 +1,69 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+const routesSample = `[
+	{"subject": ["cancelled"], "to": "-1001,0"},
+	{"system": ["D&D"], "weekday": ["sat", "Sun"], "to": "-1002,15;-1003,0"},
+	{"genre": ["хоррор"], "class": ["event-open"], "subject": ["new"], "to": "-1004,0"}
+]`
+
+func TestParseRoutes(t *testing.T) {
+	routes, err := ParseRoutes([]byte(routesSample))
+	if err != nil {
+		t.Fatalf("ParseRoutes() error = %v", err)
+	}
+	if len(routes) != 3 {
+		t.Fatalf("ParseRoutes() returned %d routes, want 3", len(routes))
+	}
+
+	for name, data := range map[string]string{
+		"not an array":    `{"to": "-1001,0"}`,
+		"unknown filter":  `[{"master": ["Иван"], "to": "-1001,0"}]`,
+		"unknown weekday": `[{"weekday": ["Sabbath"], "to": "-1001,0"}]`,
+		"report subject":  `[{"subject": ["report"], "to": "-1001,0"}]`,
+		"empty value":     `[{"system": [""], "to": "-1001,0"}]`,
+		"no destination":  `[{"system": ["D&D"]}]`,
+		"no thread":       `[{"system": ["D&D"], "to": "-1001"}]`,
+		"bad chat":        `[{"system": ["D&D"], "to": "chat,0"}]`,
+	} {
+		if _, err = ParseRoutes([]byte(data)); err == nil {
+			t.Errorf("ParseRoutes(%s) error = nil, want error", name)
+		}
+	}
+}
+
+func TestRoutes_Destination(t *testing.T) {
+	routes, err := ParseRoutes([]byte(routesSample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	moscow, _ := time.LoadLocation("Europe/Moscow")
+	saturday := time.Date(2025, 11, 1, 18, 0, 0, 0, moscow)
+	monday := time.Date(2025, 11, 3, 18, 0, 0, 0, moscow)
+
+	tests := []struct {
+		name    string
+		game    Game
+		subject SubjectType
+		want    string
+	}{
+		{name: "cancellation goes first", game: Game{System: "D&D 5e", Date: saturday}, subject: SubjectTypeCancelled, want: "-1001,0"},
+		{name: "system and weekday", game: Game{System: "D&D 5e", Date: saturday}, subject: SubjectTypeNew, want: "-1002,15;-1003,0"},
+		{name: "weekday mismatch", game: Game{System: "D&D 5e", Date: monday}, subject: SubjectTypeNew, want: ""},
+		{name: "genre and class", game: Game{Genre: "Хоррор", EventClass: "event-game event-open", Date: monday}, subject: SubjectTypeNew, want: "-1004,0"},
+		{name: "subject mismatch", game: Game{Genre: "Хоррор", EventClass: "event-open", Date: monday}, subject: SubjectTypeBecomeJoinable, want: ""},
+		{name: "class is not a substring", game: Game{Genre: "Хоррор", EventClass: "event-opened", Date: monday}, subject: SubjectTypeNew, want: ""},
+		{name: "personal subject is not routed", game: Game{System: "D&D 5e", Date: saturday}, subject: SubjectTypeFreeSeatsAdded, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := routes.Destination(&tt.game, tt.subject); got != tt.want {
+				t.Errorf("Destination() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
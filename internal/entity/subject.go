@@ -6,4 +6,12 @@ const (
 	SubjectTypeNew            SubjectType = "new"
 	SubjectTypeBecomeJoinable SubjectType = "become_joinable"
 	SubjectTypeCancelled      SubjectType = "cancelled"
+	SubjectTypeFreeSeatsAdded SubjectType = "free_seats_added"
+	// SubjectTypeSeatsChanged is fired for stored game when its seats or joinable state change
+	SubjectTypeSeatsChanged SubjectType = "seats_changed"
+	// SubjectTypeReport marks games announced by schedule:report:unnotified, it is not fired by games
+	SubjectTypeReport SubjectType = "report"
+	// SubjectTypeBulkRelease marks new games announced together by one grouped message of schedule:fetch,
+	// it is not fired by games
+	SubjectTypeBulkRelease SubjectType = "bulk_release"
 )
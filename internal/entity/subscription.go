@@ -0,0 +1,116 @@
+package entity
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/kettari/location-bot/internal/i18n"
+	"github.com/kettari/location-bot/internal/templates"
+	"gorm.io/gorm"
+)
+
+// Subscription is a set of filters for private game notifications of one Telegram user.
+// Empty filters match any game.
+type Subscription struct {
+	gorm.Model
+	TelegramID   int64  `json:"telegram_id" gorm:"index;not null"`
+	System       string `json:"system" gorm:"size:100"`
+	Genre        string `json:"genre" gorm:"size:100"`
+	Setting      string `json:"setting" gorm:"size:100"`
+	Master       string `json:"master" gorm:"size:100"`
+	Weekdays     string `json:"weekdays" gorm:"size:30"` // comma separated, e.g. "Sat,Sun"
+	TimeFrom     string `json:"time_from" gorm:"size:5"` // HH:MM in the time zone of the user
+	TimeTo       string `json:"time_to" gorm:"size:5"`   // HH:MM in the time zone of the user
+	MinFreeSeats int    `json:"min_free_seats" gorm:"default:0;not null"`
+}
+
+// Matches returns true if the game satisfies all filters of the subscription. Weekdays and the time window
+// are matched in the location of the subscriber, nil means [templates.DefaultTimezone]; the subscription
+// does not match if the time zone cannot be loaded
+func (s *Subscription) Matches(game *Game, location *time.Location) bool {
+	if location == nil {
+		var err error
+		if location, err = templates.LoadLocation(templates.DefaultTimezone); err != nil {
+			slog.Error("cannot load time zone, subscription does not match", "subscription_id", s.ID, "error", err)
+			return false
+		}
+	}
+	gameDate := game.Date.In(location)
+
+	if !containsFold(game.System, s.System) ||
+		!containsFold(game.Genre, s.Genre) ||
+		!containsFold(game.Setting, s.Setting) ||
+		!containsFold(game.MasterName, s.Master) {
+		return false
+	}
+	if len(s.Weekdays) > 0 && !strings.Contains(s.Weekdays, gameDate.Format("Mon")) {
+		return false
+	}
+	if !s.matchesTime(gameDate.Format("15:04")) {
+		return false
+	}
+	return game.SeatsFree >= s.MinFreeSeats
+}
+
+// matchesTime checks HH:MM against the time-of-day window; the window may wrap past midnight
+func (s *Subscription) matchesTime(clock string) bool {
+	if len(s.TimeFrom) == 0 || len(s.TimeTo) == 0 {
+		return true
+	}
+	if s.TimeFrom <= s.TimeTo {
+		return clock >= s.TimeFrom && clock <= s.TimeTo
+	}
+	return clock >= s.TimeFrom || clock <= s.TimeTo
+}
+
+// Describe returns human-readable list of the subscription filters in the locale
+func (s *Subscription) Describe(locale i18n.Locale) string {
+	var filters []string
+	if len(s.System) > 0 {
+		filters = append(filters, i18n.T(locale, "subscription.system", s.System))
+	}
+	if len(s.Genre) > 0 {
+		filters = append(filters, i18n.T(locale, "subscription.genre", s.Genre))
+	}
+	if len(s.Setting) > 0 {
+		filters = append(filters, i18n.T(locale, "subscription.setting", s.Setting))
+	}
+	if len(s.Master) > 0 {
+		filters = append(filters, i18n.T(locale, "subscription.master", s.Master))
+	}
+	if len(s.Weekdays) > 0 {
+		var days []string
+		for _, day := range strings.Split(s.Weekdays, ",") {
+			if weekday, ok := parseWeekday(day); ok {
+				days = append(days, strings.ToLower(i18n.Weekday(locale, weekday)))
+			}
+		}
+		filters = append(filters, i18n.T(locale, "subscription.days", strings.Join(days, ", ")))
+	}
+	if len(s.TimeFrom) > 0 && len(s.TimeTo) > 0 {
+		filters = append(filters, i18n.T(locale, "subscription.time", s.TimeFrom, s.TimeTo))
+	}
+	if s.MinFreeSeats > 0 {
+		filters = append(filters, i18n.T(locale, "subscription.seats", s.MinFreeSeats))
+	}
+	if len(filters) == 0 {
+		return i18n.T(locale, "subscription.any")
+	}
+	return strings.Join(filters, "; ")
+}
+
+// parseWeekday converts case-insensitive abbreviation, e.g. "Sat", to the weekday
+func parseWeekday(abbreviation string) (time.Weekday, bool) {
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		if strings.EqualFold(weekday.String()[:3], abbreviation) {
+			return weekday, true
+		}
+	}
+	return 0, false
+}
+
+// containsFold returns true if needle is empty or is a case-insensitive substring of haystack
+func containsFold(haystack, needle string) bool {
+	return strings.Contains(strings.ToLower(haystack), strings.ToLower(needle))
+}
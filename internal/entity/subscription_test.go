@@ -0,0 +1,76 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kettari/location-bot/internal/i18n"
+)
+
+func TestSubscription_Matches(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Saturday, 19:30 Moscow time
+	game := &Game{
+		Date:       time.Date(2025, 11, 1, 19, 30, 0, 0, moscow),
+		System:     "Pathfinder 2e",
+		Genre:      "Фэнтези",
+		Setting:    "Голарион",
+		MasterName: "Иван Иванов",
+		SeatsFree:  2,
+	}
+	// 21:30 in Yekaterinburg
+	yekaterinburg, err := time.LoadLocation("Asia/Yekaterinburg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		subscription Subscription
+		location     *time.Location
+		want         bool
+	}{
+		{name: "empty filters", subscription: Subscription{}, want: true},
+		{name: "system substring case-insensitive", subscription: Subscription{System: "pathfinder"}, want: true},
+		{name: "system mismatch", subscription: Subscription{System: "D&D"}, want: false},
+		{name: "genre cyrillic case-insensitive", subscription: Subscription{Genre: "фэнтези"}, want: true},
+		{name: "master match", subscription: Subscription{Master: "иван"}, want: true},
+		{name: "weekday match", subscription: Subscription{Weekdays: "Sat,Sun"}, want: true},
+		{name: "weekday mismatch", subscription: Subscription{Weekdays: "Mon"}, want: false},
+		{name: "time window match", subscription: Subscription{TimeFrom: "18:00", TimeTo: "23:00"}, want: true},
+		{name: "time window mismatch", subscription: Subscription{TimeFrom: "10:00", TimeTo: "15:00"}, want: false},
+		{name: "time window past midnight", subscription: Subscription{TimeFrom: "19:00", TimeTo: "02:00"}, want: true},
+		{name: "enough free seats", subscription: Subscription{MinFreeSeats: 2}, want: true},
+		{name: "not enough free seats", subscription: Subscription{MinFreeSeats: 3}, want: false},
+		{name: "all filters", subscription: Subscription{System: "Pathfinder", Weekdays: "Sat", TimeFrom: "19:00", TimeTo: "20:00", MinFreeSeats: 1}, want: true},
+		{name: "time window in user time zone", subscription: Subscription{TimeFrom: "21:00", TimeTo: "22:00"}, location: yekaterinburg, want: true},
+		{name: "time window in moscow only", subscription: Subscription{TimeFrom: "19:00", TimeTo: "20:00"}, location: yekaterinburg, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.subscription.Matches(game, tt.location); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubscription_Describe(t *testing.T) {
+	subscription := Subscription{System: "Pathfinder", Weekdays: "Sat,Sun", TimeFrom: "18:00", TimeTo: "23:00", MinFreeSeats: 2}
+	tests := map[i18n.Locale]string{
+		i18n.Russian: "система: Pathfinder; дни: суббота, воскресенье; время: 18:00–23:00; свободных мест: от 2",
+		i18n.English: "system: Pathfinder; days: saturday, sunday; time: 18:00–23:00; free seats: 2 or more",
+	}
+	for locale, want := range tests {
+		if got := subscription.Describe(locale); got != want {
+			t.Errorf("Describe(%s) = %q, want %q", locale, got, want)
+		}
+	}
+	if got := (&Subscription{}).Describe(i18n.English); got != "all games" {
+		t.Errorf("Describe() of empty subscription = %q", got)
+	}
+}
@@ -0,0 +1,102 @@
+package entity
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/kettari/location-bot/internal/i18n"
+	"github.com/kettari/location-bot/internal/templates"
+	"gorm.io/gorm"
+)
+
+// DigestHour is the hour of the day in the user time zone when the daily digest is delivered
+const DigestHour = 10
+
+// UserSettings are preferences of a Telegram user who talked to the bot
+type UserSettings struct {
+	gorm.Model
+	TelegramID int64 `json:"telegram_id" gorm:"uniqueIndex;not null"`
+	// Locale is chosen by the user with /lang; empty means the language of the Telegram client
+	Locale i18n.Locale `json:"locale" gorm:"size:8;default:'';not null"`
+	// LanguageCode is IETF language tag of the Telegram client, e.g. "en-GB", updated on every message
+	LanguageCode string `json:"language_code" gorm:"size:35;default:'';not null"`
+	// Timezone is IANA name of the time zone dates are shown in; empty means [templates.DefaultTimezone]
+	Timezone string `json:"timezone" gorm:"size:64;default:'';not null"`
+	// QuietFrom and QuietTo are HH:MM bounds of quiet hours in the user time zone, the window may wrap past midnight;
+	// private notifications are held back until quiet hours end. Empty means no quiet hours
+	QuietFrom string `json:"quiet_from" gorm:"size:5;default:'';not null"`
+	QuietTo   string `json:"quiet_to" gorm:"size:5;default:'';not null"`
+	// Digest collects private notifications into one daily message instead of sending them right away
+	Digest bool `json:"digest" gorm:"default:false;not null"`
+}
+
+// EffectiveLocale returns locale chosen by the user or matching the Telegram client language
+func (s *UserSettings) EffectiveLocale() i18n.Locale {
+	if locale, ok := i18n.Parse(string(s.Locale)); ok {
+		return locale
+	}
+	return i18n.FromLanguageCode(s.LanguageCode)
+}
+
+// Location returns the time zone chosen by the user; unknown one is replaced with [templates.DefaultTimezone],
+// and UTC is used if even that cannot be loaded
+func (s *UserSettings) Location() *time.Location {
+	if len(s.Timezone) > 0 {
+		if location, err := templates.LoadLocation(s.Timezone); err == nil {
+			return location
+		}
+	}
+	location, err := templates.LoadLocation(templates.DefaultTimezone)
+	if err != nil {
+		slog.Error("cannot load default time zone, using UTC", "telegram_id", s.TelegramID, "error", err)
+		return time.UTC
+	}
+	return location
+}
+
+// HasQuietHours returns true if quiet hours are set
+func (s *UserSettings) HasQuietHours() bool {
+	return len(s.QuietFrom) > 0 && len(s.QuietTo) > 0 && s.QuietFrom != s.QuietTo
+}
+
+// ReleaseAt returns when the private notification produced at the moment may be delivered:
+// at the next digest in digest mode, at the end of quiet hours during them, otherwise right away
+func (s *UserSettings) ReleaseAt(moment time.Time) time.Time {
+	local := moment.In(s.Location())
+	release := local
+	if s.Digest {
+		release = time.Date(local.Year(), local.Month(), local.Day(), DigestHour, 0, 0, 0, local.Location())
+		if !release.After(local) {
+			release = release.AddDate(0, 0, 1)
+		}
+	}
+	if s.quiet(release) {
+		release = s.quietEnd(release)
+	}
+	return release
+}
+
+// quiet returns true if the local time is within quiet hours; the end of the window is not quiet anymore
+func (s *UserSettings) quiet(local time.Time) bool {
+	if !s.HasQuietHours() {
+		return false
+	}
+	clock := local.Format("15:04")
+	if s.QuietFrom < s.QuietTo {
+		return clock >= s.QuietFrom && clock < s.QuietTo
+	}
+	return clock >= s.QuietFrom || clock < s.QuietTo
+}
+
+// quietEnd returns the first end of quiet hours after the local time
+func (s *UserSettings) quietEnd(local time.Time) time.Time {
+	end, err := time.ParseInLocation("15:04", s.QuietTo, local.Location())
+	if err != nil {
+		return local
+	}
+	release := time.Date(local.Year(), local.Month(), local.Day(), end.Hour(), end.Minute(), 0, 0, local.Location())
+	if !release.After(local) {
+		release = release.AddDate(0, 0, 1)
+	}
+	return release
+}
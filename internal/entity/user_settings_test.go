@@ -0,0 +1,41 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUserSettings_ReleaseAt(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2025, 1, day, hour, minute, 0, 0, moscow)
+	}
+
+	tests := []struct {
+		name     string
+		settings UserSettings
+		moment   time.Time
+		want     time.Time
+	}{
+		{"instant", UserSettings{}, at(10, 23, 30), at(10, 23, 30)},
+		{"quiet wrapping midnight, evening", UserSettings{QuietFrom: "23:00", QuietTo: "08:00"}, at(10, 23, 30), at(11, 8, 0)},
+		{"quiet wrapping midnight, night", UserSettings{QuietFrom: "23:00", QuietTo: "08:00"}, at(11, 2, 0), at(11, 8, 0)},
+		{"quiet end is not quiet", UserSettings{QuietFrom: "23:00", QuietTo: "08:00"}, at(11, 8, 0), at(11, 8, 0)},
+		{"outside quiet hours", UserSettings{QuietFrom: "13:00", QuietTo: "15:00"}, at(11, 12, 59), at(11, 12, 59)},
+		{"same bounds mean no quiet hours", UserSettings{QuietFrom: "10:00", QuietTo: "10:00"}, at(11, 10, 30), at(11, 10, 30)},
+		{"digest later today", UserSettings{Digest: true}, at(11, 7, 0), at(11, DigestHour, 0)},
+		{"digest tomorrow", UserSettings{Digest: true}, at(11, DigestHour, 0), at(12, DigestHour, 0)},
+		{"digest during quiet hours", UserSettings{Digest: true, QuietFrom: "09:00", QuietTo: "12:00"}, at(11, 7, 0), at(11, 12, 0)},
+		{"user time zone", UserSettings{Digest: true, Timezone: "Asia/Yekaterinburg"}, at(11, 7, 0), at(11, DigestHour-2, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.settings.ReleaseAt(tt.moment); !got.Equal(tt.want) {
+				t.Errorf("ReleaseAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
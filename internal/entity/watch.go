@@ -0,0 +1,12 @@
+package entity
+
+import (
+	"gorm.io/gorm"
+)
+
+// Watch is interest of a Telegram user in a particular game, usually a full one
+type Watch struct {
+	gorm.Model
+	TelegramID int64 `json:"telegram_id" gorm:"uniqueIndex:idx_watch_user_game;not null"`
+	GameID     uint  `json:"game_id" gorm:"uniqueIndex:idx_watch_user_game;index;not null"`
+}
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	tele "gopkg.in/telebot.v4"
 	"strings"
+
+	"github.com/kettari/location-bot/internal/config"
+	"github.com/kettari/location-bot/internal/i18n"
 )
 
 // isPrivate returns true if current chat is private and false if group
@@ -15,6 +18,11 @@ func isPrivate(c tele.Context) (bool, error) {
 	}
 }
 
+// replyPrivateOnly tells the group chat that commands work in private chats only, in the locale of the chat
+func replyPrivateOnly(c tele.Context) error {
+	return c.Reply(i18n.T(config.GetConfig().ChatLocale(c.Chat().ID), "private_only"))
+}
+
 func formatHumanName(guest any) string {
 	name := ""
 	// guest is telegram user object
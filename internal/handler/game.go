@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/kettari/location-bot/internal/i18n"
+	"github.com/kettari/location-bot/internal/storage"
+	tele "gopkg.in/telebot.v4"
+)
+
+// gameCardPayload prefixes game ID in the /start deep link payload
+const gameCardPayload = "game_"
+
+func NewGameHandler(manager *storage.Manager) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		slog.Info("got command /game", "from", formatHumanName(c.Sender()), "chat", formatHumanName(c.Chat()))
+		// Only in private chats
+		if private, err := isPrivate(c); err != nil {
+			return err
+		} else if !private {
+			return replyPrivateOnly(c)
+		}
+
+		return sendGameCard(c, manager, strings.TrimSpace(c.Message().Payload))
+	}
+}
+
+// sendGameCard replies with the detailed card of the game
+func sendGameCard(c tele.Context, manager *storage.Manager, reference string) error {
+	game, err := findGame(c, manager, reference, "game.help")
+	if err != nil || game == nil {
+		return err
+	}
+
+	markup := &tele.ReplyMarkup{}
+	markup.Inline(markup.Row(markup.URL(i18n.T(locale(c), "game.open"), game.URL)))
+
+	return c.Send(game.FormatCard(locale(c), location(c)), markup, &tele.SendOptions{ParseMode: tele.ModeHTML, DisableWebPagePreview: true})
+}
@@ -1,36 +1,261 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
+	"hash/fnv"
+	"html"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/kettari/location-bot/internal/config"
+	"github.com/kettari/location-bot/internal/i18n"
 	"github.com/kettari/location-bot/internal/schedule"
 	"github.com/kettari/location-bot/internal/storage"
 	tele "gopkg.in/telebot.v4"
-	"log/slog"
 )
 
-func NewGamesHandler() tele.HandlerFunc {
+// systemKeyPattern matches systemKey in callback data, empty for any system
+var systemKeyPattern = regexp.MustCompile(`^([0-9a-f]{8})?$`)
+
+// GamesButton is the unique endpoint of all /games inline keyboard callbacks
+var GamesButton = tele.Btn{Unique: "games"}
+
+// gamesState is the position and filters of the /games message, encoded into the callback data
+type gamesState struct {
+	Day int
+	// Weekday is ISO day of week, zero means any
+	Weekday int
+	// System is systemKey of the selected system, stable while the list of systems changes; empty means any
+	System      string
+	IncludeFull bool
+	// PickSystem shows system buttons instead of the games keyboard
+	PickSystem bool
+}
+
+func NewGamesHandler(manager *storage.Manager) tele.HandlerFunc {
 	return func(c tele.Context) error {
 		slog.Info("got command /games", "from", formatHumanName(c.Sender()), "chat", formatHumanName(c.Chat()))
 		// Only in private chats
 		if private, err := isPrivate(c); err != nil {
 			return err
 		} else if !private {
-			return c.Reply("Команды работают только в личной переписке")
+			return replyPrivateOnly(c)
+		}
+
+		text, markup, err := renderGames(manager, locale(c), location(c), gamesState{})
+		if err != nil {
+			return err
+		}
+		return c.Send(text, markup, &tele.SendOptions{ParseMode: tele.ModeHTML, DisableWebPagePreview: true})
+	}
+}
+
+// NewGamesCallbackHandler edits /games message in place after inline keyboard button press
+func NewGamesCallbackHandler(manager *storage.Manager) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		slog.Debug("got /games callback", "data", c.Callback().Data)
+		state := parseGamesState(c.Callback().Data)
+
+		text, markup, err := renderGames(manager, locale(c), location(c), state)
+		if err != nil {
+			return err
+		}
+		err = c.Edit(text, markup, &tele.SendOptions{ParseMode: tele.ModeHTML, DisableWebPagePreview: true})
+		if err != nil && !errors.Is(err, tele.ErrSameMessageContent) && !errors.Is(err, tele.ErrMessageNotModified) {
+			return err
+		}
+		return c.Respond()
+	}
+}
+
+// renderGames loads games for the state and returns message text with inline keyboard in the locale,
+// days and dates are in the time zone
+func renderGames(manager *storage.Manager, locale i18n.Locale, location *time.Location, state gamesState) (string, *tele.ReplyMarkup, error) {
+	conf := config.GetConfig()
+	sch := schedule.NewSchedule(manager)
+	sch.Locale = locale
+	sch.Location = location
+
+	filter := schedule.EventFilter{Weekday: state.Weekday, IncludeFull: state.IncludeFull}
+	systems, err := sch.JoinableSystems(filter)
+	if err != nil {
+		return "", nil, err
+	}
+	// The system may disappear from the list after the message was rendered
+	filter.System = findSystem(systems, state.System)
+	if len(filter.System) == 0 {
+		state.System = ""
+	}
+
+	if state.PickSystem {
+		return i18n.T(locale, "games.pick_system"), systemsMarkup(locale, state, systems), nil
+	}
+
+	if err = sch.LoadJoinableEvents(filter); err != nil {
+		return "", nil, err
+	}
+	days, err := sch.Days()
+	if err != nil {
+		return "", nil, err
+	}
+	state.Day = max(0, min(state.Day, len(days)-1))
+
+	text := i18n.T(locale, "games.joinable")
+	if state.IncludeFull {
+		text = i18n.T(locale, "games.all")
+	}
+	if len(filter.System) > 0 {
+		text += fmt.Sprintf(" (%s)", html.EscapeString(filter.System))
+	}
+	text += ":"
+	if len(days) == 0 {
+		text += "\n\n" + i18n.T(locale, "games.none")
+	} else {
+		dayText, err := sch.FormatDay(days[state.Day], conf.BotUsername)
+		if err != nil {
+			return "", nil, err
+		}
+		text += dayText
+	}
+
+	return text, gamesMarkup(locale, state, len(days), filter.System), nil
+}
+
+// gamesMarkup builds keyboard with day pagination and filter toggles
+func gamesMarkup(locale i18n.Locale, state gamesState, daysCount int, system string) *tele.ReplyMarkup {
+	markup := &tele.ReplyMarkup{}
+	var rows []tele.Row
+
+	if daysCount > 1 {
+		previous, next := state, state
+		previous.Day = (state.Day - 1 + daysCount) % daysCount
+		next.Day = (state.Day + 1) % daysCount
+		rows = append(rows, markup.Row(
+			gamesButton(markup, i18n.T(locale, "games.previous"), previous),
+			gamesButton(markup, fmt.Sprintf("%d/%d", state.Day+1, daysCount), state),
+			gamesButton(markup, i18n.T(locale, "games.next"), next),
+		))
+	}
+
+	var weekdays []tele.Btn
+	for weekday, label := range strings.Split(i18n.T(locale, "games.weekdays"), "|") {
+		selected := state
+		selected.Weekday = weekday
+		selected.Day = 0
+		if weekday == state.Weekday {
+			label = "• " + label
+		}
+		weekdays = append(weekdays, gamesButton(markup, label, selected))
+	}
+	rows = append(rows, markup.Row(weekdays[:4]...), markup.Row(weekdays[4:]...))
+
+	systemLabel := i18n.T(locale, "games.system_any")
+	if len(system) > 0 {
+		systemLabel = i18n.T(locale, "games.system", system)
+	}
+	pick := state
+	pick.PickSystem = true
+	rows = append(rows, markup.Row(gamesButton(markup, systemLabel, pick)))
+
+	toggle := state
+	toggle.IncludeFull = !state.IncludeFull
+	toggle.Day = 0
+	freeLabel := "✅ " + i18n.T(locale, "games.only_free")
+	if state.IncludeFull {
+		freeLabel = "☐ " + i18n.T(locale, "games.only_free")
+	}
+	rows = append(rows, markup.Row(gamesButton(markup, freeLabel, toggle)))
+
+	markup.Inline(rows...)
+	return markup
+}
+
+// systemsMarkup builds keyboard to pick the system filter
+func systemsMarkup(locale i18n.Locale, state gamesState, systems []string) *tele.ReplyMarkup {
+	markup := &tele.ReplyMarkup{}
+	state.PickSystem = false
+	state.Day = 0
+
+	all := state
+	all.System = ""
+	rows := []tele.Row{markup.Row(gamesButton(markup, i18n.T(locale, "games.systems_all"), all))}
+	for _, system := range systems {
+		selected := state
+		selected.System = systemKey(system)
+		rows = append(rows, markup.Row(gamesButton(markup, system, selected)))
+	}
+
+	markup.Inline(rows...)
+	return markup
+}
+
+func gamesButton(markup *tele.ReplyMarkup, label string, state gamesState) tele.Btn {
+	return markup.Data(label, GamesButton.Unique, state.String())
+}
+
+// systemKey returns short hash of the system name to fit into callback data
+func systemKey(system string) string {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(system))
+	return fmt.Sprintf("%08x", hash.Sum32())
+}
+
+// findSystem returns the system with the key, empty if there is none
+func findSystem(systems []string, key string) string {
+	if len(key) == 0 {
+		return ""
+	}
+	for _, system := range systems {
+		if systemKey(system) == key {
+			return system
 		}
+	}
+	return ""
+}
 
-		if c.Message() != nil && c.Message().Sender != nil {
-			conf := config.GetConfig()
-			manager := storage.NewManager(conf.DbConnectionString)
-			sch := schedule.NewSchedule(manager)
-			if err := sch.LoadJoinableEvents(); err != nil {
-				return err
-			}
-			if err := sch.ExecuteFullReport(fmt.Sprintf("%d,0", c.Message().Sender.ID)); err != nil {
-				return err
-			}
+// String encodes state as "day|weekday|system|include_full|pick_system" to fit 64 bytes of callback data
+func (s gamesState) String() string {
+	return fmt.Sprintf("%d|%d|%s|%d|%d", s.Day, s.Weekday, s.System, boolToInt(s.IncludeFull), boolToInt(s.PickSystem))
+}
+
+// parseGamesState decodes callback data; malformed data resets the state
+func parseGamesState(data string) gamesState {
+	state := gamesState{}
+	fields := strings.Split(data, "|")
+	if len(fields) != 5 || !systemKeyPattern.MatchString(fields[2]) {
+		return state
+	}
+	var values [5]int
+	for k, field := range fields {
+		if k == 2 {
+			continue
 		}
+		value, err := strconv.Atoi(field)
+		if err != nil {
+			return state
+		}
+		values[k] = value
+	}
+	if values[1] < 0 || values[1] > 7 {
+		return state
+	}
+
+	return gamesState{
+		Day:         max(0, values[0]),
+		Weekday:     values[1],
+		System:      fields[2],
+		IncludeFull: values[3] == 1,
+		PickSystem:  values[4] == 1,
+	}
+}
 
-		return nil
+func boolToInt(value bool) int {
+	if value {
+		return 1
 	}
+	return 0
 }
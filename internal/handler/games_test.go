@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kettari/location-bot/internal/i18n"
+)
+
+func TestGamesState_RoundTrip(t *testing.T) {
+	tests := []gamesState{
+		{},
+		{Day: 3, Weekday: 6, System: systemKey("Dungeons & Dragons 5e"), IncludeFull: true},
+		{Weekday: 7, PickSystem: true},
+	}
+	for _, state := range tests {
+		t.Run(state.String(), func(t *testing.T) {
+			data := state.String()
+			if len(data) > 58 {
+				t.Errorf("encoded state %q does not fit into callback data", data)
+			}
+			if got := parseGamesState(data); got != state {
+				t.Errorf("parseGamesState(%q) = %+v, want %+v", data, got, state)
+			}
+		})
+	}
+}
+
+func TestParseGamesState_Malformed(t *testing.T) {
+	tests := []string{"", "1|2|3", "a|0||0|0", "0|8||0|0", "0|0|-1|0|0", "0|0|xyz|0|0"}
+	for _, data := range tests {
+		t.Run(data, func(t *testing.T) {
+			if got := parseGamesState(data); got != (gamesState{}) {
+				t.Errorf("parseGamesState(%q) = %+v, want default state", data, got)
+			}
+		})
+	}
+}
+
+func TestFindSystem(t *testing.T) {
+	systems := []string{"Dungeons & Dragons 5e", "Pathfinder 2e", "Call of Cthulhu"}
+	key := systemKey("Pathfinder 2e")
+	if got := findSystem(systems, key); got != "Pathfinder 2e" {
+		t.Errorf("findSystem() = %q, want Pathfinder 2e", got)
+	}
+	// The list changed after the button was rendered: the key still selects the same system
+	if got := findSystem(append([]string{"Blades in the Dark"}, systems...), key); got != "Pathfinder 2e" {
+		t.Errorf("findSystem() after the list changed = %q, want Pathfinder 2e", got)
+	}
+	if got := findSystem(systems[:1], key); got != "" {
+		t.Errorf("findSystem() of the system gone = %q, want any system", got)
+	}
+}
+
+func TestWeekdayButtons(t *testing.T) {
+	for _, locale := range i18n.Locales() {
+		if labels := strings.Split(i18n.T(locale, "games.weekdays"), "|"); len(labels) != 8 {
+			t.Errorf("locale %s has %d weekday buttons, want 8: %q", locale, len(labels), labels)
+		}
+	}
+}
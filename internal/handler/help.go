@@ -3,14 +3,9 @@ package handler
 import (
 	tele "gopkg.in/telebot.v4"
 	"log/slog"
-)
-
-const commonHelp = `Этот бот умеет высылать список игр, на которые <a href="https://rolecon.ru/">можно записаться в клубе «Локация»</a>, г. Москва. 
 
-Команды:
-
-/games — список игр в Локации, на которые можно записаться
-/help — эта справка`
+	"github.com/kettari/location-bot/internal/i18n"
+)
 
 func NewHelpHandler() tele.HandlerFunc {
 	return func(c tele.Context) error {
@@ -19,8 +14,8 @@ func NewHelpHandler() tele.HandlerFunc {
 		if private, err := isPrivate(c); err != nil {
 			return err
 		} else if !private {
-			return c.Reply("Команды работают только в личной переписке")
+			return replyPrivateOnly(c)
 		}
-		return c.Send(commonHelp, &tele.SendOptions{ParseMode: tele.ModeHTML, DisableWebPagePreview: true})
+		return c.Send(i18n.T(locale(c), "help"), &tele.SendOptions{ParseMode: tele.ModeHTML, DisableWebPagePreview: true})
 	}
 }
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/kettari/location-bot/internal/config"
+	"github.com/kettari/location-bot/internal/entity"
+	"github.com/kettari/location-bot/internal/i18n"
+	"github.com/kettari/location-bot/internal/storage"
+	tele "gopkg.in/telebot.v4"
+)
+
+// settingsKey stores settings of the user in the context
+const settingsKey = "settings"
+
+// NewLocaleMiddleware remembers Telegram client language of the user, so notifications are sent in it,
+// and puts settings of the user into the context for handlers
+func NewLocaleMiddleware(manager *storage.Manager) tele.MiddlewareFunc {
+	return func(next tele.HandlerFunc) tele.HandlerFunc {
+		return func(c tele.Context) error {
+			if sender := c.Sender(); sender != nil && !sender.IsBot {
+				c.Set(settingsKey, userSettings(manager, sender))
+			}
+			return next(c)
+		}
+	}
+}
+
+// userSettings loads settings of the user and stores Telegram client language only when it changed, so most
+// updates only read; database failure does not stop the handler, settings with the client language are used instead
+func userSettings(manager *storage.Manager, sender *tele.User) *entity.UserSettings {
+	settings, err := manager.FindUserSettings(sender.ID)
+	if err != nil {
+		slog.Error("cannot load user settings", "telegram_id", sender.ID, "error", err)
+		return &entity.UserSettings{TelegramID: sender.ID, LanguageCode: sender.LanguageCode}
+	}
+	if len(sender.LanguageCode) > 0 && settings.LanguageCode != sender.LanguageCode {
+		settings.LanguageCode = sender.LanguageCode
+		if err = manager.SaveLanguageCode(sender.ID, sender.LanguageCode); err != nil {
+			slog.Error("cannot save user settings", "telegram_id", sender.ID, "error", err)
+		}
+	}
+	return settings
+}
+
+// locale returns locale of the user set by [NewLocaleMiddleware] or matching the Telegram client language
+func locale(c tele.Context) i18n.Locale {
+	if settings, ok := c.Get(settingsKey).(*entity.UserSettings); ok {
+		return settings.EffectiveLocale()
+	}
+	if sender := c.Sender(); sender != nil {
+		return i18n.FromLanguageCode(sender.LanguageCode)
+	}
+	return config.GetConfig().Locale
+}
+
+// location returns the time zone chosen by the user, nil means the default one
+func location(c tele.Context) *time.Location {
+	if settings, ok := c.Get(settingsKey).(*entity.UserSettings); ok {
+		return settings.Location()
+	}
+	return nil
+}
+
+// localeName returns name of the locale in the language of the user
+func localeName(userLocale, locale i18n.Locale) string {
+	return i18n.T(userLocale, "lang.name."+string(locale))
+}
+
+func NewLangHandler(manager *storage.Manager) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		slog.Info("got command /lang", "from", formatHumanName(c.Sender()), "chat", formatHumanName(c.Chat()))
+		// Only in private chats
+		if private, err := isPrivate(c); err != nil {
+			return err
+		} else if !private {
+			return replyPrivateOnly(c)
+		}
+
+		current := locale(c)
+		payload := strings.ToLower(strings.TrimSpace(c.Message().Payload))
+		chosen, ok := i18n.Parse(payload)
+		if payload != "auto" && !ok {
+			return c.Send(i18n.T(current, "lang.help", localeName(current, current)), &tele.SendOptions{ParseMode: tele.ModeHTML})
+		}
+
+		settings, err := manager.FindUserSettings(c.Sender().ID)
+		if err != nil {
+			return err
+		}
+		settings.Locale = chosen
+		if len(c.Sender().LanguageCode) > 0 {
+			settings.LanguageCode = c.Sender().LanguageCode
+		}
+		if err = manager.SaveUserSettings(settings); err != nil {
+			return err
+		}
+
+		current = settings.EffectiveLocale()
+		c.Set(settingsKey, settings)
+		if payload == "auto" {
+			return c.Send(i18n.T(current, "lang.auto", localeName(current, current)))
+		}
+		return c.Send(i18n.T(current, "lang.changed", localeName(current, current)))
+	}
+}
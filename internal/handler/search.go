@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"fmt"
+	"html"
+	"log/slog"
+	"strings"
+
+	"github.com/kettari/location-bot/internal/config"
+	"github.com/kettari/location-bot/internal/i18n"
+	"github.com/kettari/location-bot/internal/storage"
+	"github.com/kettari/location-bot/internal/templates"
+	tele "gopkg.in/telebot.v4"
+)
+
+const searchResultsLimit = 10
+
+func NewSearchHandler(manager *storage.Manager) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		slog.Info("got command /search", "from", formatHumanName(c.Sender()), "chat", formatHumanName(c.Chat()))
+		// Only in private chats
+		if private, err := isPrivate(c); err != nil {
+			return err
+		} else if !private {
+			return replyPrivateOnly(c)
+		}
+
+		query := strings.TrimSpace(c.Message().Payload)
+		if len(query) == 0 {
+			return c.Send(i18n.T(locale(c), "search.help"), &tele.SendOptions{ParseMode: tele.ModeHTML})
+		}
+
+		conf := config.GetConfig()
+		games, err := manager.SearchGames(query, false, searchResultsLimit)
+		if err != nil {
+			return err
+		}
+		if len(games) == 0 {
+			return c.Send(i18n.T(locale(c), "search.none"))
+		}
+
+		timezone := location(c)
+		if timezone == nil {
+			if timezone, err = templates.LoadLocation(templates.DefaultTimezone); err != nil {
+				return err
+			}
+		}
+		result := i18n.T(locale(c), "search.results", html.EscapeString(query)) + "\n"
+		for _, game := range games {
+			result += fmt.Sprintf("\n🔸 %s %d/%d <a href=\"%s\">%s</a> [%s] <a href=\"%s\">ℹ️</a>",
+				game.Date.In(timezone).Format("02.01 15:04"),
+				game.SeatsFree,
+				game.SeatsTotal,
+				game.URL,
+				html.EscapeString(game.Title),
+				html.EscapeString(game.System),
+				game.CardURL(conf.BotUsername))
+		}
+
+		return c.Send(result, &tele.SendOptions{ParseMode: tele.ModeHTML, DisableWebPagePreview: true})
+	}
+}
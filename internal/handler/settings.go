@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"errors"
+	"html"
+	"log/slog"
+	"strings"
+
+	"github.com/kettari/location-bot/internal/entity"
+	"github.com/kettari/location-bot/internal/i18n"
+	"github.com/kettari/location-bot/internal/storage"
+	"github.com/kettari/location-bot/internal/templates"
+	tele "gopkg.in/telebot.v4"
+)
+
+// SettingsButton is the unique endpoint of all /settings inline keyboard callbacks
+var SettingsButton = tele.Btn{Unique: "settings"}
+
+// settingsTimezones are time zones offered by the /settings keyboard, others are set with /settings tz
+var settingsTimezones = []string{
+	"Europe/Kaliningrad", "Europe/Moscow", "Europe/Samara",
+	"Asia/Yekaterinburg", "Asia/Novosibirsk", "Asia/Vladivostok",
+	"UTC",
+}
+
+// settingsQuietHours are quiet hours offered by the /settings keyboard; "off" disables them
+var settingsQuietHours = []string{"off", "22:00-08:00", "23:00-09:00", "00:00-10:00"}
+
+func NewSettingsHandler(manager *storage.Manager) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		slog.Info("got command /settings", "from", formatHumanName(c.Sender()), "chat", formatHumanName(c.Chat()))
+		// Only in private chats
+		if private, err := isPrivate(c); err != nil {
+			return err
+		} else if !private {
+			return replyPrivateOnly(c)
+		}
+
+		settings, err := manager.FindUserSettings(c.Sender().ID)
+		if err != nil {
+			return err
+		}
+
+		if kind, value, found := strings.Cut(strings.TrimSpace(c.Message().Payload), " "); found {
+			if err = applySetting(settings, strings.ToLower(kind), strings.TrimSpace(value)); err != nil {
+				return c.Send(html.EscapeString(i18n.Message(locale(c), err))+"\n\n"+i18n.T(locale(c), "settings.help"),
+					&tele.SendOptions{ParseMode: tele.ModeHTML})
+			}
+			if err = saveSettings(c, manager, settings); err != nil {
+				return err
+			}
+		}
+
+		return c.Send(formatSettings(settings), settingsMarkup(settings), &tele.SendOptions{ParseMode: tele.ModeHTML})
+	}
+}
+
+// NewSettingsCallbackHandler applies the setting chosen with inline keyboard and edits /settings message in place
+func NewSettingsCallbackHandler(manager *storage.Manager) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		slog.Debug("got /settings callback", "data", c.Callback().Data)
+		settings, err := manager.FindUserSettings(c.Sender().ID)
+		if err != nil {
+			return err
+		}
+
+		kind, value, _ := strings.Cut(c.Callback().Data, "|")
+		if err = applySetting(settings, kind, value); err != nil {
+			return c.Respond(&tele.CallbackResponse{Text: i18n.Message(locale(c), err)})
+		}
+		if err = saveSettings(c, manager, settings); err != nil {
+			return err
+		}
+
+		err = c.Edit(formatSettings(settings), settingsMarkup(settings), &tele.SendOptions{ParseMode: tele.ModeHTML})
+		if err != nil && !errors.Is(err, tele.ErrSameMessageContent) && !errors.Is(err, tele.ErrMessageNotModified) {
+			return err
+		}
+		return c.Respond()
+	}
+}
+
+// applySetting changes the setting of the kind "tz", "quiet" or "digest" to the value
+func applySetting(settings *entity.UserSettings, kind, value string) error {
+	switch kind {
+	case "tz":
+		if _, err := templates.LoadLocation(value); err != nil || len(value) == 0 {
+			return i18n.Errorf("settings.error.timezone", value)
+		}
+		settings.Timezone = value
+	case "quiet":
+		if strings.ToLower(value) == "off" {
+			settings.QuietFrom, settings.QuietTo = "", ""
+			return nil
+		}
+		from, to, err := parseTimeWindow(value)
+		if err != nil {
+			return err
+		}
+		settings.QuietFrom, settings.QuietTo = from, to
+	case "digest":
+		switch strings.ToLower(value) {
+		case "on":
+			settings.Digest = true
+		case "off":
+			settings.Digest = false
+		default:
+			return i18n.Errorf("settings.error.digest", value)
+		}
+	default:
+		return i18n.Errorf("settings.error.kind", kind)
+	}
+	return nil
+}
+
+// saveSettings stores settings of the user and applies them to private notifications waiting in the outbox
+func saveSettings(c tele.Context, manager *storage.Manager, settings *entity.UserSettings) error {
+	if err := manager.SaveUserSettings(settings); err != nil {
+		return err
+	}
+	c.Set(settingsKey, settings)
+	if err := manager.RescheduleNotifications(settings); err != nil {
+		slog.Error("cannot reschedule notifications of the user", "telegram_id", settings.TelegramID, "error", err)
+	}
+	return nil
+}
+
+// formatSettings returns summary of the user settings in the user locale
+func formatSettings(settings *entity.UserSettings) string {
+	locale := settings.EffectiveLocale()
+	quiet := i18n.T(locale, "settings.quiet_off")
+	if settings.HasQuietHours() {
+		quiet = settings.QuietFrom + "–" + settings.QuietTo
+	}
+	mode := i18n.T(locale, "settings.mode.instant")
+	if settings.Digest {
+		mode = i18n.T(locale, "settings.mode.digest", entity.DigestHour)
+	}
+	return i18n.T(locale, "settings.summary", settings.Location().String(), quiet, mode) + "\n\n" + i18n.T(locale, "settings.help")
+}
+
+// settingsMarkup builds keyboard with time zones, quiet hours and the digest toggle; current choices are checked
+func settingsMarkup(settings *entity.UserSettings) *tele.ReplyMarkup {
+	locale := settings.EffectiveLocale()
+	markup := &tele.ReplyMarkup{}
+	var rows []tele.Row
+
+	var timezones []tele.Btn
+	for _, timezone := range settingsTimezones {
+		label := strings.ReplaceAll(timezone[strings.LastIndex(timezone, "/")+1:], "_", " ")
+		timezones = append(timezones, settingsButton(markup, label, settings.Location().String() == timezone, "tz", timezone))
+	}
+	rows = append(rows, markup.Split(3, timezones)...)
+
+	var quiet []tele.Btn
+	for _, window := range settingsQuietHours {
+		label := strings.ReplaceAll(window, "-", "–")
+		current := settings.QuietFrom + "-" + settings.QuietTo
+		if window == "off" {
+			label = i18n.T(locale, "settings.quiet_off")
+			current = window
+			if settings.HasQuietHours() {
+				current = ""
+			}
+		}
+		quiet = append(quiet, settingsButton(markup, label, current == window, "quiet", window))
+	}
+	rows = append(rows, markup.Row(quiet...))
+
+	rows = append(rows, markup.Row(
+		settingsButton(markup, i18n.T(locale, "settings.button.instant"), !settings.Digest, "digest", "off"),
+		settingsButton(markup, i18n.T(locale, "settings.button.digest"), settings.Digest, "digest", "on"),
+	))
+
+	markup.Inline(rows...)
+	return markup
+}
+
+// settingsButton returns button setting the kind to the value, the checked one is marked
+func settingsButton(markup *tele.ReplyMarkup, label string, checked bool, kind, value string) tele.Btn {
+	if checked {
+		label = "✅ " + label
+	}
+	return markup.Data(label, SettingsButton.Unique, kind, value)
+}
@@ -3,11 +3,18 @@ package handler
 import (
 	tele "gopkg.in/telebot.v4"
 	"log/slog"
+	"strings"
+
+	"github.com/kettari/location-bot/internal/storage"
 )
 
-func NewStartHandler() tele.HandlerFunc {
+func NewStartHandler(manager *storage.Manager) tele.HandlerFunc {
 	return func(c tele.Context) error {
 		slog.Info("got command /start", "from", formatHumanName(c.Sender()), "chat", formatHumanName(c.Chat()))
+		// Deep link from the games list opens the game card
+		if c.Message() != nil && strings.HasPrefix(c.Message().Payload, gameCardPayload) {
+			return sendGameCard(c, manager, strings.TrimPrefix(c.Message().Payload, gameCardPayload))
+		}
 		h := NewHelpHandler()
 		return h(c)
 	}
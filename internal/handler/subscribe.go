@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"fmt"
+	"html"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kettari/location-bot/internal/entity"
+	"github.com/kettari/location-bot/internal/i18n"
+	"github.com/kettari/location-bot/internal/storage"
+	tele "gopkg.in/telebot.v4"
+)
+
+var weekdayAliases = map[string]string{
+	"пн": "Mon", "понедельник": "Mon", "mon": "Mon", "monday": "Mon",
+	"вт": "Tue", "вторник": "Tue", "tue": "Tue", "tuesday": "Tue",
+	"ср": "Wed", "среда": "Wed", "wed": "Wed", "wednesday": "Wed",
+	"чт": "Thu", "четверг": "Thu", "thu": "Thu", "thursday": "Thu",
+	"пт": "Fri", "пятница": "Fri", "fri": "Fri", "friday": "Fri",
+	"сб": "Sat", "суббота": "Sat", "sat": "Sat", "saturday": "Sat",
+	"вс": "Sun", "воскресенье": "Sun", "sun": "Sun", "sunday": "Sun",
+}
+
+func NewSubscribeHandler(manager *storage.Manager) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		slog.Info("got command /subscribe", "from", formatHumanName(c.Sender()), "chat", formatHumanName(c.Chat()))
+		// Only in private chats
+		if private, err := isPrivate(c); err != nil {
+			return err
+		} else if !private {
+			return replyPrivateOnly(c)
+		}
+
+		payload := c.Message().Payload
+		if strings.TrimSpace(payload) == "help" {
+			return c.Send(i18n.T(locale(c), "subscribe.help"), &tele.SendOptions{ParseMode: tele.ModeHTML})
+		}
+		subscription, err := parseSubscription(payload)
+		if err != nil {
+			return c.Send(i18n.T(locale(c), "subscribe.failed", html.EscapeString(i18n.Message(locale(c), err)))+"\n\n"+i18n.T(locale(c), "subscribe.help"),
+				&tele.SendOptions{ParseMode: tele.ModeHTML})
+		}
+		subscription.TelegramID = c.Sender().ID
+
+		if err = manager.CreateSubscription(subscription); err != nil {
+			return err
+		}
+
+		return c.Send(i18n.T(locale(c), "subscribe.created", subscription.ID, subscription.Describe(locale(c))))
+	}
+}
+
+func NewUnsubscribeHandler(manager *storage.Manager) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		slog.Info("got command /unsubscribe", "from", formatHumanName(c.Sender()), "chat", formatHumanName(c.Chat()))
+		// Only in private chats
+		if private, err := isPrivate(c); err != nil {
+			return err
+		} else if !private {
+			return replyPrivateOnly(c)
+		}
+
+		// Zero ID means all subscriptions
+		var subscriptionID uint64
+		payload := strings.TrimPrefix(strings.TrimSpace(c.Message().Payload), "#")
+		if len(payload) > 0 && payload != "all" {
+			var err error
+			if subscriptionID, err = strconv.ParseUint(payload, 10, 0); err != nil || subscriptionID == 0 {
+				return c.Send(i18n.T(locale(c), "subscribe.bad_id"), &tele.SendOptions{ParseMode: tele.ModeHTML})
+			}
+		}
+
+		deleted, err := manager.DeleteSubscription(c.Sender().ID, uint(subscriptionID))
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return c.Send(i18n.T(locale(c), "subscribe.not_found"))
+		}
+
+		return c.Send(i18n.T(locale(c), "subscribe.deleted", deleted))
+	}
+}
+
+func NewSubscriptionsHandler(manager *storage.Manager) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		slog.Info("got command /subscriptions", "from", formatHumanName(c.Sender()), "chat", formatHumanName(c.Chat()))
+		// Only in private chats
+		if private, err := isPrivate(c); err != nil {
+			return err
+		} else if !private {
+			return replyPrivateOnly(c)
+		}
+
+		subscriptions, err := manager.FindSubscriptions(c.Sender().ID)
+		if err != nil {
+			return err
+		}
+		if len(subscriptions) == 0 {
+			return c.Send(i18n.T(locale(c), "subscribe.none"), &tele.SendOptions{ParseMode: tele.ModeHTML})
+		}
+
+		result := i18n.T(locale(c), "subscribe.list") + "\n"
+		for _, subscription := range subscriptions {
+			result += fmt.Sprintf("\n#%d — %s", subscription.ID, html.EscapeString(subscription.Describe(locale(c))))
+		}
+		result += "\n\n" + i18n.T(locale(c), "subscribe.list_footer")
+
+		return c.Send(result, &tele.SendOptions{ParseMode: tele.ModeHTML})
+	}
+}
+
+// parseSubscription parses "key=value; key=value" filters of the /subscribe command; errors are [i18n.Error]
+func parseSubscription(payload string) (*entity.Subscription, error) {
+	subscription := &entity.Subscription{}
+	for _, filter := range strings.Split(payload, ";") {
+		filter = strings.TrimSpace(filter)
+		if len(filter) == 0 {
+			continue
+		}
+		key, value, found := strings.Cut(filter, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if !found || len(value) == 0 {
+			return nil, i18n.Errorf("subscribe.error.format", filter)
+		}
+
+		switch key {
+		case "система", "system":
+			subscription.System = value
+		case "жанр", "genre":
+			subscription.Genre = value
+		case "сеттинг", "setting":
+			subscription.Setting = value
+		case "мастер", "master":
+			subscription.Master = value
+		case "день", "дни", "weekday", "weekdays":
+			weekdays, err := parseWeekdays(value)
+			if err != nil {
+				return nil, err
+			}
+			subscription.Weekdays = weekdays
+		case "время", "time":
+			from, to, err := parseTimeWindow(value)
+			if err != nil {
+				return nil, err
+			}
+			subscription.TimeFrom, subscription.TimeTo = from, to
+		case "места", "seats":
+			seats, err := strconv.Atoi(value)
+			if err != nil || seats < 0 {
+				return nil, i18n.Errorf("subscribe.error.seats", value)
+			}
+			subscription.MinFreeSeats = seats
+		default:
+			return nil, i18n.Errorf("subscribe.error.filter", key)
+		}
+	}
+	return subscription, nil
+}
+
+// parseWeekdays converts "сб,вс" to "Sat,Sun"
+func parseWeekdays(value string) (string, error) {
+	var weekdays []string
+	for _, day := range strings.Split(value, ",") {
+		day = strings.ToLower(strings.TrimSpace(day))
+		weekday, ok := weekdayAliases[day]
+		if !ok {
+			return "", i18n.Errorf("subscribe.error.weekday", day)
+		}
+		weekdays = append(weekdays, weekday)
+	}
+	return strings.Join(weekdays, ","), nil
+}
+
+// parseTimeWindow converts "18:00-23:00" to its bounds
+func parseTimeWindow(value string) (from, to string, err error) {
+	value = strings.ReplaceAll(value, "–", "-")
+	from, to, found := strings.Cut(value, "-")
+	if !found {
+		return "", "", i18n.Errorf("subscribe.error.time", value)
+	}
+	for _, bound := range []*string{&from, &to} {
+		parsed, parseErr := time.Parse("15:04", strings.TrimSpace(*bound))
+		if parseErr != nil {
+			return "", "", i18n.Errorf("subscribe.error.time", value)
+		}
+		*bound = parsed.Format("15:04")
+	}
+	return from, to, nil
+}
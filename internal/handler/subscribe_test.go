@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/kettari/location-bot/internal/entity"
+	"github.com/kettari/location-bot/internal/i18n"
+)
+
+func TestParseSubscription(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    entity.Subscription
+		wantErr bool
+	}{
+		{
+			name:    "empty payload",
+			payload: "",
+			want:    entity.Subscription{},
+		},
+		{
+			name:    "all filters in russian",
+			payload: "система=Pathfinder; жанр=хоррор; сеттинг=Голарион; мастер=Иван; день=сб,вс; время=18:00-23:00; места=2",
+			want: entity.Subscription{
+				System: "Pathfinder", Genre: "хоррор", Setting: "Голарион", Master: "Иван",
+				Weekdays: "Sat,Sun", TimeFrom: "18:00", TimeTo: "23:00", MinFreeSeats: 2,
+			},
+		},
+		{
+			name:    "english keys and en dash",
+			payload: "system=D&D 5e;weekday=Fri;time=9:00–12:30",
+			want:    entity.Subscription{System: "D&D 5e", Weekdays: "Fri", TimeFrom: "09:00", TimeTo: "12:30"},
+		},
+		{name: "unknown key", payload: "цвет=синий", wantErr: true},
+		{name: "missing value", payload: "система=", wantErr: true},
+		{name: "bad weekday", payload: "день=завтра", wantErr: true},
+		{name: "bad time", payload: "время=вечером", wantErr: true},
+		{name: "negative seats", payload: "места=-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSubscription(tt.payload)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSubscription() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if message := i18n.Message(i18n.English, err); message == err.Error() {
+					t.Errorf("error %q is not localized", message)
+				}
+				return
+			}
+			if *got != tt.want {
+				t.Errorf("parseSubscription() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/kettari/location-bot/internal/entity"
+	"github.com/kettari/location-bot/internal/i18n"
+	"github.com/kettari/location-bot/internal/schedule"
+	"github.com/kettari/location-bot/internal/storage"
+	tele "gopkg.in/telebot.v4"
+)
+
+func NewWatchHandler(manager *storage.Manager) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		slog.Info("got command /watch", "from", formatHumanName(c.Sender()), "chat", formatHumanName(c.Chat()))
+		// Only in private chats
+		if private, err := isPrivate(c); err != nil {
+			return err
+		} else if !private {
+			return replyPrivateOnly(c)
+		}
+
+		reference := strings.TrimSpace(c.Message().Payload)
+		if len(reference) == 0 {
+			return sendWatchedGames(c, manager)
+		}
+
+		game, err := findGame(c, manager, reference, "watch.help")
+		if err != nil || game == nil {
+			return err
+		}
+		if !game.Date.After(time.Now()) {
+			return c.Send(i18n.T(locale(c), "watch.past"))
+		}
+		if err = manager.CreateWatch(c.Sender().ID, game.ID); err != nil {
+			return err
+		}
+
+		result := i18n.T(locale(c), "watch.created", html.EscapeString(game.Title))
+		if game.Joinable {
+			result += "\n\n" + i18n.T(locale(c), "watch.joinable", game.SeatsFree, game.SeatsTotal, game.URL)
+		}
+		return c.Send(result, &tele.SendOptions{ParseMode: tele.ModeHTML, DisableWebPagePreview: true})
+	}
+}
+
+func NewUnwatchHandler(manager *storage.Manager) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		slog.Info("got command /unwatch", "from", formatHumanName(c.Sender()), "chat", formatHumanName(c.Chat()))
+		// Only in private chats
+		if private, err := isPrivate(c); err != nil {
+			return err
+		} else if !private {
+			return replyPrivateOnly(c)
+		}
+
+		reference := strings.TrimSpace(c.Message().Payload)
+		if len(reference) == 0 {
+			return c.Send(i18n.T(locale(c), "unwatch.help"))
+		}
+
+		game, err := findGame(c, manager, reference, "watch.help")
+		if err != nil || game == nil {
+			return err
+		}
+		deleted, err := manager.DeleteWatch(c.Sender().ID, game.ID)
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return c.Send(i18n.T(locale(c), "unwatch.not_found"))
+		}
+
+		return c.Send(i18n.T(locale(c), "unwatch.deleted", game.Title))
+	}
+}
+
+// findGame resolves reference to the stored game; replies with the help message and returns nil game if not found
+func findGame(c tele.Context, manager *storage.Manager, reference, helpKey string) (*entity.Game, error) {
+	sch := schedule.NewSchedule(manager)
+	game, err := sch.FindGame(reference)
+	if errors.Is(err, schedule.ErrGameNotFound) {
+		return nil, c.Send(i18n.T(locale(c), helpKey), &tele.SendOptions{ParseMode: tele.ModeHTML, DisableWebPagePreview: true})
+	}
+	if errors.Is(err, schedule.ErrGameAmbiguous) {
+		return nil, c.Send(i18n.T(locale(c), "game.ambiguous"))
+	}
+	return game, err
+}
+
+// sendWatchedGames lists games watched by the user
+func sendWatchedGames(c tele.Context, manager *storage.Manager) error {
+	games, err := manager.FindWatchedGames(c.Sender().ID)
+	if err != nil {
+		return err
+	}
+	if len(games) == 0 {
+		return c.Send(i18n.T(locale(c), "watch.help"), &tele.SendOptions{ParseMode: tele.ModeHTML, DisableWebPagePreview: true})
+	}
+
+	result := i18n.T(locale(c), "watch.list") + "\n"
+	for _, game := range games {
+		result += fmt.Sprintf("\n#%d %d/%d <a href=\"%s\">%s</a>", game.ID, game.SeatsFree, game.SeatsTotal, game.URL, html.EscapeString(game.Title))
+	}
+	result += "\n\n" + i18n.T(locale(c), "watch.list_footer")
+
+	return c.Send(result, &tele.SendOptions{ParseMode: tele.ModeHTML, DisableWebPagePreview: true})
+}
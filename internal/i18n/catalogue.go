@@ -0,0 +1,265 @@
+package i18n
+
+// catalogue maps message keys to texts; every locale has the same keys and format verbs, see the test
+var catalogue = map[Locale]map[string]string{
+	Russian: {
+		"private_only": "Команды работают только в личной переписке",
+		"help": `Этот бот умеет высылать список игр, на которые <a href="https://rolecon.ru/">можно записаться в клубе «Локация»</a>, г. Москва. 
+
+Команды:
+
+/games — список игр в Локации, на которые можно записаться
+/game — подробная карточка игры по номеру или ссылке
+/search — поиск игр по названию, системе, мастеру и описанию
+/subscribe — подписаться на личные уведомления об играх (справка: /subscribe help)
+/subscriptions — список ваших подписок
+/unsubscribe — отписаться от уведомлений
+/watch — следить за заполненной игрой и узнать, когда освободится место
+/unwatch — перестать следить за игрой
+/lang — язык бота
+/settings — часовой пояс, тихие часы и дайджест уведомлений
+/help — эта справка`,
+
+		"lang.help":    "Язык бота: %s. Сменить: <code>/lang ru</code>, <code>/lang en</code> или <code>/lang auto</code> — по языку Telegram.",
+		"lang.changed": "Язык бота: %s",
+		"lang.auto":    "Язык бота выбирается по языку Telegram: %s",
+		"lang.name.ru": "русский",
+		"lang.name.en": "английский",
+
+		"settings.summary": `Настройки:
+
+Часовой пояс: %s
+Тихие часы: %s
+Личные уведомления: %s`,
+		"settings.help": `Выберите кнопками ниже или командой:
+<code>/settings tz Asia/Yekaterinburg</code> — любой часовой пояс IANA
+<code>/settings quiet 23:00-08:00</code> или <code>/settings quiet off</code>
+<code>/settings digest on</code> или <code>/settings digest off</code>
+
+В тихие часы личные уведомления придерживаются и приходят, когда они закончатся.`,
+		"settings.quiet_off":      "нет",
+		"settings.mode.instant":   "сразу",
+		"settings.mode.digest":    "одним дайджестом в %d:00",
+		"settings.button.instant": "Сразу",
+		"settings.button.digest":  "Дайджест раз в день",
+		"settings.error.timezone": "Неизвестный часовой пояс «%s», укажите имя IANA, например Europe/Moscow",
+		"settings.error.digest":   "Непонятное значение «%s», укажите on или off",
+		"settings.error.kind":     "Непонятная настройка «%s»",
+		"digest.header":           "Дайджест уведомлений (%d):",
+
+		"digest.title.day":         "📋 <b>Расписание за день</b> (%s – %s)",
+		"digest.title.week":        "📋 <b>Расписание за неделю</b> (%s – %s)",
+		"digest.empty":             "Изменений нет, открытых игр на выходных тоже.",
+		"digest.section.new":       "🆕 <b>Новые игры (%d):</b>",
+		"digest.section.reopened":  "🔓 <b>Снова можно записаться (%d):</b>",
+		"digest.section.filled":    "🈵 <b>Мест не осталось (%d):</b>",
+		"digest.section.cancelled": "❌ <b>Отменены (%d):</b>",
+		"digest.section.weekend":   "🎲 <b>Открытые игры на выходных (%d):</b>",
+		"bulk_release.header":      "🆕 <b>Опубликовано новых игр: %d</b>",
+
+		"anomaly.alert":                 "⚠️ Отмена пропавших игр пропущена: %s. Загружено игр: %d, сохранено будущих: %d, из них пропали: %d. Проверьте сайт и парсер.",
+		"anomaly.reason.no_games":       "не загружено ни одной игры",
+		"anomaly.reason.absent_count":   "пропало слишком много игр",
+		"anomaly.reason.absent_percent": "пропала слишком большая доля игр",
+
+		"game.help": `Игра не найдена. Укажите номер игры или ссылку на неё на rolecon.ru, например:
+
+<code>/game https://rolecon.ru/event/12345</code>`,
+		"game.open":      "Открыть на rolecon.ru",
+		"game.ambiguous": "По этой ссылке несколько игр, укажите номер игры",
+
+		"games.weekdays":    "Все дни|Пн|Вт|Ср|Чт|Пт|Сб|Вс",
+		"games.pick_system": "Выберите систему:",
+		"games.joinable":    "Игры, на которые можно записаться",
+		"games.all":         "Игры в расписании",
+		"games.none":        "Подходящих игр нет.",
+		"games.previous":    "« День",
+		"games.next":        "День »",
+		"games.system":      "Система: %s",
+		"games.system_any":  "Система: все",
+		"games.only_free":   "Только со свободными местами",
+		"games.systems_all": "Все системы",
+
+		"search.help": `Поиск по названию, описанию, системе, сеттингу и мастеру будущих игр, например:
+
+<code>/search pathfinder</code>
+<code>/search "клинки во тьме"</code>
+<code>/search хоррор -ктулху</code>`,
+		"search.none":    "Ничего не найдено",
+		"search.results": "Найдено по запросу «%s»:",
+
+		"subscribe.help": `Подписка на личные уведомления о новых играх и освободившихся местах.
+
+Фильтры перечисляются через точку с запятой, все необязательны:
+
+<code>/subscribe система=Pathfinder; жанр=хоррор; сеттинг=Голарион; мастер=Иван; день=сб,вс; время=18:00-23:00; места=2</code>
+
+<code>/subscribe</code> без фильтров — уведомления обо всех играх.`,
+		"subscribe.failed":        "Не удалось оформить подписку: %s",
+		"subscribe.created":       "Подписка #%d оформлена: %s",
+		"subscribe.bad_id":        "Укажите номер подписки из /subscriptions или <code>all</code>, чтобы отписаться от всех",
+		"subscribe.not_found":     "Подписка не найдена",
+		"subscribe.deleted":       "Удалено подписок: %d",
+		"subscribe.none":          "Подписок нет. Оформить: /subscribe, справка: <code>/subscribe help</code>",
+		"subscribe.list":          "Ваши подписки:",
+		"subscribe.list_footer":   "Отписаться: /unsubscribe &lt;номер&gt; или /unsubscribe all",
+		"subscribe.error.format":  "фильтр «%s» должен быть в формате ключ=значение",
+		"subscribe.error.seats":   "количество мест «%s» должно быть неотрицательным числом",
+		"subscribe.error.filter":  "неизвестный фильтр «%s»",
+		"subscribe.error.weekday": "неизвестный день недели «%s»",
+		"subscribe.error.time":    "время «%s» должно быть в формате ЧЧ:ММ-ЧЧ:ММ",
+
+		"subscription.system":  "система: %s",
+		"subscription.genre":   "жанр: %s",
+		"subscription.setting": "сеттинг: %s",
+		"subscription.master":  "мастер: %s",
+		"subscription.days":    "дни: %s",
+		"subscription.time":    "время: %s–%s",
+		"subscription.seats":   "свободных мест: от %d",
+		"subscription.any":     "все игры",
+
+		"watch.help": `Укажите номер игры или ссылку на неё на rolecon.ru, например:
+
+<code>/watch https://rolecon.ru/event/12345</code>
+
+Когда в игре освободится место, бот напишет вам в личные сообщения.`,
+		"watch.past":        "Эта игра уже прошла",
+		"watch.created":     "Слежу за игрой «%s». Напишу, когда освободится место.",
+		"watch.joinable":    "Свободные места есть уже сейчас: %d/%d, <a href=\"%s\">записаться</a>.",
+		"watch.list":        "Вы следите за играми:",
+		"watch.list_footer": "Перестать следить: /unwatch &lt;номер&gt;",
+		"unwatch.help":      "Укажите номер игры из списка /watch",
+		"unwatch.not_found": "Вы не следите за этой игрой",
+		"unwatch.deleted":   "Больше не слежу за игрой «%s»",
+	},
+	English: {
+		"private_only": "Commands work in private chat only",
+		"help": `This bot lists games you <a href="https://rolecon.ru/">can join at the Lokatsiya club</a>, Moscow.
+
+Commands:
+
+/games — games at Lokatsiya open for joining
+/game — detailed game card by its number or link
+/search — search games by title, system, master and description
+/subscribe — subscribe to private game notifications (help: /subscribe help)
+/subscriptions — your subscriptions
+/unsubscribe — unsubscribe from notifications
+/watch — watch a full game and learn when a seat is free
+/unwatch — stop watching the game
+/lang — bot language
+/settings — time zone, quiet hours and notification digest
+/help — this help`,
+
+		"lang.help":    "Bot language: %s. Change: <code>/lang ru</code>, <code>/lang en</code> or <code>/lang auto</code> to follow Telegram language.",
+		"lang.changed": "Bot language: %s",
+		"lang.auto":    "Bot language follows Telegram language: %s",
+		"lang.name.ru": "Russian",
+		"lang.name.en": "English",
+
+		"settings.summary": `Settings:
+
+Time zone: %s
+Quiet hours: %s
+Private notifications: %s`,
+		"settings.help": `Choose with the buttons below or with a command:
+<code>/settings tz Asia/Yekaterinburg</code> — any IANA time zone
+<code>/settings quiet 23:00-08:00</code> or <code>/settings quiet off</code>
+<code>/settings digest on</code> or <code>/settings digest off</code>
+
+Private notifications are held back during quiet hours and arrive when they end.`,
+		"settings.quiet_off":      "none",
+		"settings.mode.instant":   "instantly",
+		"settings.mode.digest":    "in one digest at %d:00",
+		"settings.button.instant": "Instantly",
+		"settings.button.digest":  "Daily digest",
+		"settings.error.timezone": "Unknown time zone \"%s\", use IANA name, e.g. Europe/Moscow",
+		"settings.error.digest":   "Unclear value \"%s\", use on or off",
+		"settings.error.kind":     "Unclear setting \"%s\"",
+		"digest.header":           "Notification digest (%d):",
+
+		"digest.title.day":         "📋 <b>Schedule of the day</b> (%s – %s)",
+		"digest.title.week":        "📋 <b>Schedule of the week</b> (%s – %s)",
+		"digest.empty":             "No changes and no open games this weekend.",
+		"digest.section.new":       "🆕 <b>New games (%d):</b>",
+		"digest.section.reopened":  "🔓 <b>Open for joining again (%d):</b>",
+		"digest.section.filled":    "🈵 <b>Filled up (%d):</b>",
+		"digest.section.cancelled": "❌ <b>Cancelled (%d):</b>",
+		"digest.section.weekend":   "🎲 <b>Open games this weekend (%d):</b>",
+		"bulk_release.header":      "🆕 <b>New games published: %d</b>",
+
+		"anomaly.alert":                 "⚠️ Cancellation of absent games skipped: %s. Games parsed: %d, future games stored: %d, absent of them: %d. Check the site and the parser.",
+		"anomaly.reason.no_games":       "no games parsed",
+		"anomaly.reason.absent_count":   "too many games are absent",
+		"anomaly.reason.absent_percent": "too large share of games is absent",
+
+		"game.help": `Game not found. Send the game number or its link on rolecon.ru, e.g.:
+
+<code>/game https://rolecon.ru/event/12345</code>`,
+		"game.open":      "Open on rolecon.ru",
+		"game.ambiguous": "The link matches several games, send the game number",
+
+		"games.weekdays":    "Any day|Mo|Tu|We|Th|Fr|Sa|Su",
+		"games.pick_system": "Pick the system:",
+		"games.joinable":    "Games open for joining",
+		"games.all":         "Scheduled games",
+		"games.none":        "No matching games.",
+		"games.previous":    "« Day",
+		"games.next":        "Day »",
+		"games.system":      "System: %s",
+		"games.system_any":  "System: any",
+		"games.only_free":   "Only with free seats",
+		"games.systems_all": "All systems",
+
+		"search.help": `Search future games by title, description, system, setting and master, e.g.:
+
+<code>/search pathfinder</code>
+<code>/search "blades in the dark"</code>
+<code>/search horror -cthulhu</code>`,
+		"search.none":    "Nothing found",
+		"search.results": "Found for «%s»:",
+
+		"subscribe.help": `Private notifications about new games and free seats.
+
+Filters are separated with semicolons, all optional:
+
+<code>/subscribe system=Pathfinder; genre=horror; setting=Golarion; master=Ivan; weekday=sat,sun; time=18:00-23:00; seats=2</code>
+
+<code>/subscribe</code> without filters notifies about all games.`,
+		"subscribe.failed":        "Cannot subscribe: %s",
+		"subscribe.created":       "Subscription #%d created: %s",
+		"subscribe.bad_id":        "Send the subscription number from /subscriptions or <code>all</code> to unsubscribe from all",
+		"subscribe.not_found":     "Subscription not found",
+		"subscribe.deleted":       "Subscriptions deleted: %d",
+		"subscribe.none":          "No subscriptions. Subscribe: /subscribe, help: <code>/subscribe help</code>",
+		"subscribe.list":          "Your subscriptions:",
+		"subscribe.list_footer":   "Unsubscribe: /unsubscribe &lt;number&gt; or /unsubscribe all",
+		"subscribe.error.format":  "filter «%s» must be key=value",
+		"subscribe.error.seats":   "seats «%s» must be a non-negative number",
+		"subscribe.error.filter":  "unknown filter «%s»",
+		"subscribe.error.weekday": "unknown weekday «%s»",
+		"subscribe.error.time":    "time «%s» must be HH:MM-HH:MM",
+
+		"subscription.system":  "system: %s",
+		"subscription.genre":   "genre: %s",
+		"subscription.setting": "setting: %s",
+		"subscription.master":  "master: %s",
+		"subscription.days":    "days: %s",
+		"subscription.time":    "time: %s–%s",
+		"subscription.seats":   "free seats: %d or more",
+		"subscription.any":     "all games",
+
+		"watch.help": `Send the game number or its link on rolecon.ru, e.g.:
+
+<code>/watch https://rolecon.ru/event/12345</code>
+
+The bot will message you privately when a seat is free.`,
+		"watch.past":        "The game is over",
+		"watch.created":     "Watching «%s». I'll message you when a seat is free.",
+		"watch.joinable":    "There are free seats already: %d/%d, <a href=\"%s\">join</a>.",
+		"watch.list":        "You watch games:",
+		"watch.list_footer": "Stop watching: /unwatch &lt;number&gt;",
+		"unwatch.help":      "Send the game number from /watch",
+		"unwatch.not_found": "You do not watch this game",
+		"unwatch.deleted":   "Not watching «%s» anymore",
+	},
+}
@@ -0,0 +1,98 @@
+// Package i18n is the catalogue of user-visible texts in supported locales
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+type Locale string
+
+const (
+	Russian Locale = "ru"
+	English Locale = "en"
+	// Default is used for unknown languages and messages missing in the locale
+	Default = Russian
+)
+
+// Locales returns supported locales, the default one first
+func Locales() []Locale {
+	return []Locale{Russian, English}
+}
+
+// Parse returns supported locale by its code, e.g. "en"
+func Parse(code string) (Locale, bool) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	for _, locale := range Locales() {
+		if string(locale) == code {
+			return locale, true
+		}
+	}
+	return "", false
+}
+
+// FromLanguageCode picks locale for the IETF language tag of the Telegram user, e.g. "en-GB"
+func FromLanguageCode(code string) Locale {
+	language, _, _ := strings.Cut(code, "-")
+	if locale, ok := Parse(language); ok {
+		return locale
+	}
+	return Default
+}
+
+// T returns the message in the locale formatted with args like [fmt.Sprintf]. Message missing in the locale
+// is taken from the default one
+func T(locale Locale, key string, args ...any) string {
+	message, ok := catalogue[locale][key]
+	if !ok {
+		if message, ok = catalogue[Default][key]; !ok {
+			slog.Error("message not found in catalogue", "key", key, "locale", locale)
+			return key
+		}
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+var weekdays = map[Locale][7]string{
+	Russian: {"ВОСКРЕСЕНЬЕ", "ПОНЕДЕЛЬНИК", "ВТОРНИК", "СРЕДА", "ЧЕТВЕРГ", "ПЯТНИЦА", "СУББОТА"},
+	English: {"SUNDAY", "MONDAY", "TUESDAY", "WEDNESDAY", "THURSDAY", "FRIDAY", "SATURDAY"},
+}
+
+// Weekday returns name of the day in upper case, e.g. СУББОТА
+func Weekday(locale Locale, weekday time.Weekday) string {
+	names, ok := weekdays[locale]
+	if !ok {
+		names = weekdays[Default]
+	}
+	return names[weekday]
+}
+
+// Error is the error shown to the user; [Error.Error] returns the message in the default locale
+type Error struct {
+	Key  string
+	Args []any
+}
+
+// Errorf returns [Error] with the catalogue message
+func Errorf(key string, args ...any) *Error {
+	return &Error{Key: key, Args: args}
+}
+
+func (e *Error) Error() string {
+	return T(Default, e.Key, e.Args...)
+}
+
+// Message returns text of the error in the locale; errors not from the catalogue are returned as is
+func Message(locale Locale, err error) string {
+	var localized *Error
+	if errors.As(err, &localized) {
+		return T(locale, localized.Key, localized.Args...)
+	}
+	return err.Error()
+}
@@ -0,0 +1,74 @@
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+)
+
+var verbPattern = regexp.MustCompile(`%[a-z%]`)
+
+func TestCatalogue_Complete(t *testing.T) {
+	for _, locale := range Locales() {
+		if _, ok := catalogue[locale]; !ok {
+			t.Fatalf("locale %s has no messages", locale)
+		}
+	}
+	for key, message := range catalogue[Default] {
+		for _, locale := range Locales() {
+			translated, ok := catalogue[locale][key]
+			if !ok {
+				t.Errorf("message %q is missing in locale %s", key, locale)
+				continue
+			}
+			want, got := verbPattern.FindAllString(message, -1), verbPattern.FindAllString(translated, -1)
+			if !slices.Equal(want, got) {
+				t.Errorf("message %q in locale %s has verbs %v, want %v", key, locale, got, want)
+			}
+		}
+	}
+	for _, locale := range Locales() {
+		for key := range catalogue[locale] {
+			if _, ok := catalogue[Default][key]; !ok {
+				t.Errorf("message %q of locale %s is missing in the default locale", key, locale)
+			}
+		}
+	}
+}
+
+func TestFromLanguageCode(t *testing.T) {
+	tests := map[string]Locale{"en": English, "en-GB": English, "EN": English, "ru": Russian, "de": Default, "": Default}
+	for code, want := range tests {
+		if got := FromLanguageCode(code); got != want {
+			t.Errorf("FromLanguageCode(%q) = %s, want %s", code, got, want)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	if got := T(English, "subscribe.deleted", 2); got != "Subscriptions deleted: 2" {
+		t.Errorf("T() = %q", got)
+	}
+	if got := T("de", "search.none"); got != catalogue[Default]["search.none"] {
+		t.Errorf("T() of unknown locale = %q, want default one", got)
+	}
+	if got := T(English, "no.such.key"); got != "no.such.key" {
+		t.Errorf("T() of unknown key = %q, want the key", got)
+	}
+	if got := Weekday(English, time.Saturday); got != "SATURDAY" {
+		t.Errorf("Weekday() = %q", got)
+	}
+}
+
+func TestMessage(t *testing.T) {
+	err := fmt.Errorf("parse: %w", Errorf("subscribe.error.filter", "color"))
+	if got := Message(English, err); got != "unknown filter «color»" {
+		t.Errorf("Message() = %q", got)
+	}
+	if got := Message(English, errors.New("plain")); got != "plain" {
+		t.Errorf("Message() of plain error = %q", got)
+	}
+}
@@ -65,6 +65,7 @@ func (he *HtmlEngine) Process(page *scraper.Page) (*[]entity.Game, error) {
 			game := entity.Game{
 				ExternalID: id,
 				URL:        page.URL,
+				PageURL:    page.URL,
 				Slot:       slot,
 			}
 			he.processEventNode(n, &game)
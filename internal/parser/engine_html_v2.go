@@ -66,6 +66,9 @@ func (he *HtmlEngineV2) ProcessWithEvents(page *scraper.Page, eventMap map[strin
 	if eventMap != nil {
 		if event, ok := eventMap[page.URL]; ok {
 			he.fallbackToEventDates(games, event, page.Html)
+			for k := range games {
+				games[k].EventClass = strings.Join(event.ClassName, " ")
+			}
 		}
 	}
 
@@ -121,6 +124,7 @@ func (he *HtmlEngineV2) createGameFromDiv(n *html.Node, page *scraper.Page) enti
 	return entity.Game{
 		ExternalID: id,
 		URL:        page.URL,
+		PageURL:    page.URL,
 		Slot:       slot,
 	}
 }
@@ -758,11 +758,12 @@ func TestHtmlEngineV2_ProcessWithEvents_RealHTMLExample(t *testing.T) {
 	}
 
 	event := scraper.RoleconEvent{
-		ID:    18475,
-		Title: "Охота: Война в тени",
-		URL:   "/game/18475",
-		Start: "2025-10-24T19:00:00+03:00", // Friday, Oct 24, 2025
-		End:   "2025-10-24T23:00:00+03:00",
+		ID:        18475,
+		Title:     "Охота: Война в тени",
+		URL:       "/game/18475",
+		Start:     "2025-10-24T19:00:00+03:00", // Friday, Oct 24, 2025
+		End:       "2025-10-24T23:00:00+03:00",
+		ClassName: []string{"event-game", "event-open"},
 	}
 
 	page := &scraper.Page{
@@ -791,6 +792,9 @@ func TestHtmlEngineV2_ProcessWithEvents_RealHTMLExample(t *testing.T) {
 	if game.Title != "Охота: Война в тени" {
 		t.Errorf("Title = %s, want 'Охота: Война в тени'", game.Title)
 	}
+	if game.EventClass != "event-game event-open" {
+		t.Errorf("EventClass = %q, want classes of the calendar event", game.EventClass)
+	}
 
 	// Verify that date was set from event metadata (fallback)
 	// This is the main purpose of this test: verify that when a page
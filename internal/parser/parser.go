@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"context"
+
 	"github.com/kettari/location-bot/internal/entity"
 	"github.com/kettari/location-bot/internal/scraper"
 )
@@ -26,8 +28,12 @@ func (p *Parser) Parse(pages *[]scraper.Page, collection entity.Collection) erro
 	return nil
 }
 
-func (p *Parser) ParseWithEvents(result *scraper.FetchResult, collection entity.Collection) error {
+// ParseWithEvents adds games of fetched pages to the collection; cancelled ctx stops parsing before the next page
+func (p *Parser) ParseWithEvents(ctx context.Context, result *scraper.FetchResult, collection entity.Collection) error {
 	for _, page := range result.Pages {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		games, err := p.engine.ProcessWithEvents(&page, result.EventMap)
 		if err != nil {
 			return err
@@ -0,0 +1,120 @@
+package schedule
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/kettari/location-bot/internal/bot"
+	"github.com/kettari/location-bot/internal/config"
+	"github.com/kettari/location-bot/internal/templates"
+)
+
+const (
+	// boardMessageLimit leaves room for the footer below the Telegram 4096 characters limit
+	boardMessageLimit = 3800
+	// boardPlaceholder fills board messages not needed for the current schedule, Telegram does not allow empty ones
+	boardPlaceholder = "…"
+)
+
+// FormatBoard returns the loaded games in the [Schedule.Format] layout split into exactly size messages.
+// Games which do not fit are only counted; unused messages are filled with the placeholder
+func (s *Schedule) FormatBoard(size int, updated time.Time) ([]string, error) {
+	if size < 1 {
+		return nil, errors.New("board size must be positive")
+	}
+
+	s.sortGames()
+
+	messages := []string{templates.Render(s.Locale, templates.ScheduleHeader, nil)}
+	if len(s.Games) == 0 {
+		messages[0] = templates.Render(s.Locale, templates.ScheduleEmpty, nil)
+	}
+	currentDate := ""
+	skipped := 0
+	for _, game := range s.Games {
+		if skipped > 0 {
+			skipped++
+			continue
+		}
+
+		gameDate := s.formatGameDate(&game)
+		record := s.formatGameRecord(&game, "")
+		text := "\n" + record
+		if currentDate != gameDate {
+			text = "\n\n" + gameDate + text
+		}
+
+		last := len(messages) - 1
+		if len(messages[last])+len(text) > boardMessageLimit {
+			if len(messages) == size {
+				skipped++
+				continue
+			}
+			// Next message starts with the date header again
+			messages = append(messages, gameDate+"\n"+record)
+		} else {
+			messages[last] += text
+		}
+		currentDate = gameDate
+	}
+
+	footer := "\n\n" + templates.Render(s.Locale, templates.BoardUpdated, updated)
+	if skipped > 0 {
+		footer = "\n\n" + templates.Render(s.Locale, templates.ScheduleMore, skipped) + footer
+	}
+	messages[len(messages)-1] += footer
+	for len(messages) < size {
+		messages = append(messages, boardPlaceholder)
+	}
+
+	return messages, nil
+}
+
+// UpdateBoard edits the schedule board in the recipients chats to show the loaded games
+//
+// Destination format: chat_id_1,thread_id_1;chat_id_2,thread_id_2
+func (s *Schedule) UpdateBoard(destination string, size int) error {
+	conf := config.GetConfig()
+	if conf.DryRun {
+		slog.Info("DRY RUN MODE: skipping schedule board update")
+		return nil
+	}
+	if s.manager == nil {
+		return errors.New("manager not initialized")
+	}
+
+	posted, err := s.manager.BoardMessages()
+	if err != nil {
+		return err
+	}
+	// Chats of every locale get their own board
+	now := time.Now()
+	for _, locale := range conf.RecipientLocales(destination) {
+		s.Locale = locale
+		parts, err := s.FormatBoard(size, now)
+		if err != nil {
+			return err
+		}
+		b, err := bot.CreateBoardBot(conf.BotToken, conf.RecipientsIn(destination, locale), s.limiter)
+		if err != nil {
+			slog.Error("unable to create bot processor object", "error", err)
+			return err
+		}
+
+		// Save reposted messages even if some chats failed, so they are edited next time
+		board, removed, updateErr := b.UpdateBoard(posted, parts)
+		if err = s.manager.SaveBoardMessages(board); err != nil {
+			return err
+		}
+		if err = s.manager.DeleteBoardMessages(removed); err != nil {
+			return err
+		}
+		if updateErr != nil {
+			return updateErr
+		}
+		slog.Info("schedule board updated", "locale", locale, "games_count", len(s.Games), "messages_count", len(parts), "removed_count", len(removed))
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kettari/location-bot/internal/entity"
+	"github.com/kettari/location-bot/internal/i18n"
+)
+
+func boardGames(count int) []entity.Game {
+	var games []entity.Game
+	start := time.Date(2025, 11, 1, 13, 0, 0, 0, time.UTC)
+	for k := 0; k < count; k++ {
+		games = append(games, entity.Game{
+			ExternalID: fmt.Sprintf("game%d", k),
+			Title:      fmt.Sprintf("Игра номер %d с довольно длинным названием", k),
+			URL:        fmt.Sprintf("https://rolecon.ru/event/%d", k),
+			Date:       start.Add(time.Duration(k/4) * 24 * time.Hour),
+			System:     "Pathfinder",
+			Setting:    "Голарион",
+			SeatsTotal: 5,
+			SeatsFree:  2,
+			Joinable:   true,
+		})
+	}
+	return games
+}
+
+func TestSchedule_FormatBoard(t *testing.T) {
+	updated := time.Date(2025, 10, 31, 12, 30, 0, 0, time.UTC)
+
+	t.Run("empty schedule", func(t *testing.T) {
+		s := NewSchedule(nil)
+		messages, err := s.FormatBoard(3, updated)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(messages) != 3 {
+			t.Fatalf("got %d messages, want 3", len(messages))
+		}
+		if !strings.HasPrefix(messages[0], "Открытых игр для записи на сайте нет.") || !strings.Contains(messages[0], "Обновлено 31.10 15:30") {
+			t.Errorf("unexpected first message: %q", messages[0])
+		}
+		if messages[1] != boardPlaceholder || messages[2] != boardPlaceholder {
+			t.Errorf("unused messages are not placeholders: %q", messages[1:])
+		}
+	})
+
+	t.Run("split into fixed set", func(t *testing.T) {
+		s := NewSchedule(nil)
+		s.Add(boardGames(30)...)
+		messages, err := s.FormatBoard(3, updated)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(messages) != 3 {
+			t.Fatalf("got %d messages, want 3", len(messages))
+		}
+		for k, message := range messages {
+			if len(message) > 4096 {
+				t.Errorf("message %d is %d bytes long", k, len(message))
+			}
+		}
+		if !strings.HasPrefix(messages[1], "<b>") {
+			t.Errorf("continuation does not start with the date header: %q", messages[1][:40])
+		}
+		if all := strings.Join(messages, ""); strings.Contains(all, "…и ещё игр") || strings.Count(all, "🔸") != 30 {
+			t.Error("not all games are shown on the board")
+		}
+	})
+
+	t.Run("overflow is counted", func(t *testing.T) {
+		s := NewSchedule(nil)
+		s.Add(boardGames(60)...)
+		messages, err := s.FormatBoard(2, updated)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(messages) != 2 {
+			t.Fatalf("got %d messages, want 2", len(messages))
+		}
+		if !strings.Contains(messages[1], "…и ещё игр: ") || !strings.HasSuffix(messages[1], "Обновлено 31.10 15:30") {
+			t.Errorf("overflow note missing: %q", messages[1][len(messages[1])-100:])
+		}
+		shown := strings.Count(strings.Join(messages, ""), "🔸")
+		var skipped int
+		fmt.Sscanf(messages[1][strings.Index(messages[1], "…и ещё игр: ")+len("…и ещё игр: "):], "%d", &skipped)
+		if shown+skipped != 60 {
+			t.Errorf("shown %d and skipped %d games, want 60 in total", shown, skipped)
+		}
+	})
+	t.Run("locale", func(t *testing.T) {
+		s := NewSchedule(nil)
+		s.Locale = i18n.English
+		s.Add(boardGames(1)...)
+		messages, err := s.FormatBoard(1, updated)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(messages[0], "Games open for joining:\n\n<b>SATURDAY</b>") || !strings.HasSuffix(messages[0], "Updated 31.10 15:30") {
+			t.Errorf("unexpected English board: %q", messages[0])
+		}
+	})
+}
@@ -0,0 +1,160 @@
+package schedule
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/kettari/location-bot/internal/bot"
+	"github.com/kettari/location-bot/internal/config"
+	"github.com/kettari/location-bot/internal/entity"
+	"github.com/kettari/location-bot/internal/i18n"
+	"github.com/kettari/location-bot/internal/storage"
+)
+
+// markBulkRelease flags new games of the bulk release, see [Schedule.UseBulkRelease]. Without database
+// every new joinable game counts as new
+func (s *Schedule) markBulkRelease(manager *storage.Manager) error {
+	if s.bulkRun == 0 && s.bulkPage == 0 {
+		return nil
+	}
+
+	stored := make(map[string]bool)
+	if manager != nil {
+		if err := manager.Connect(); err != nil {
+			return err
+		}
+		var externalIDs, storedIDs []string
+		for _, game := range s.Games {
+			externalIDs = append(externalIDs, game.ExternalID)
+		}
+		if err := manager.DB().
+			Model(&entity.Game{}).
+			Where("external_id IN ?", externalIDs).
+			Pluck("external_id", &storedIDs).Error; err != nil {
+			return err
+		}
+		for _, id := range storedIDs {
+			stored[id] = true
+		}
+	}
+
+	var fresh []int
+	pages := make(map[string]int)
+	for k := range s.Games {
+		if !stored[s.Games[k].ExternalID] && s.Games[k].NewJoinable() {
+			fresh = append(fresh, k)
+			pages[pageURL(&s.Games[k])]++
+		}
+	}
+	bulkRun := s.bulkRun > 0 && len(fresh) > s.bulkRun
+	released := 0
+	for _, k := range fresh {
+		if bulkRun || (s.bulkPage > 0 && pages[pageURL(&s.Games[k])] > s.bulkPage) {
+			s.Games[k].BulkRelease = true
+			released++
+		}
+	}
+	if released > 0 {
+		slog.Info("bulk release detected", "new_games_count", len(fresh), "released_count", released, "whole_run", bulkRun)
+	}
+	return nil
+}
+
+// pageURL returns the event page the game was parsed from; games stored before pages were recorded
+// fall back to the game URL
+func pageURL(game *entity.Game) string {
+	if len(game.PageURL) > 0 {
+		return game.PageURL
+	}
+	return game.URL
+}
+
+// announceBulkRelease sends one grouped message about games of the bulk release directly, without outbox
+func (s *Schedule) announceBulkRelease() error {
+	var games []entity.Game
+	for k := range s.Games {
+		if s.Games[k].BulkRelease {
+			games = append(games, s.Games[k])
+		}
+	}
+	if len(games) == 0 {
+		return nil
+	}
+	slog.Info("announcing bulk release", "games_count", len(games))
+
+	conf := config.GetConfig()
+	b, err := bot.CreateBot(conf.BotToken, conf.NotificationChatID, s.limiter)
+	if err != nil {
+		slog.Error("unable to create bot processor object", "error", err)
+		return err
+	}
+	return entity.SendPartsLocalized(b, bulkReleaseFormat(games))
+}
+
+// saveBulkRelease stores games of the bulk release in one transaction together with their notifications and
+// the grouped message, so a failed or cancelled run loses neither: the next run finds the games new again.
+// The message is attached to the first game and every other game is recorded as announced by it, so
+// [Schedule.LoadUnnotifiedEvents] does not report them again. Without outbox the games are already saved
+// by [Schedule.SaveGames] and the message is sent directly
+func (s *Schedule) saveBulkRelease(manager *storage.Manager) error {
+	if s.outbox == nil {
+		return s.announceBulkRelease()
+	}
+	var games []*entity.Game
+	for k := range s.Games {
+		if s.Games[k].BulkRelease {
+			games = append(games, &s.Games[k])
+		}
+	}
+	if len(games) == 0 {
+		return nil
+	}
+	slog.Info("announcing bulk release", "games_count", len(games))
+
+	now := time.Now()
+	return manager.Transaction(func(tx *storage.Manager) error {
+		// Drop notifications left by the rolled back transaction, if any
+		s.outbox.Take()
+		for _, game := range games {
+			storedGame := *game
+			if err := tx.DB().Save(game).Error; err != nil {
+				return err
+			}
+			for _, subject := range gameEvents(game, &storedGame, true) {
+				s.outbox.Begin(game, subject, storedGame.UpdatedAt)
+				notify(game, []entity.SubjectType{subject})
+			}
+		}
+		notifications := s.outbox.Take()
+
+		release := &Schedule{}
+		for _, game := range games {
+			release.Games = append(release.Games, *game)
+		}
+		s.outbox.Begin(&release.Games[0], entity.SubjectTypeBulkRelease, now)
+		if err := entity.SendPartsLocalized(s.outbox, bulkReleaseFormat(release.Games)); err != nil {
+			return err
+		}
+		notifications = append(notifications, s.outbox.Take()...)
+		for k := 1; k < len(release.Games); k++ {
+			s.outbox.Begin(&release.Games[k], entity.SubjectTypeBulkRelease, now)
+			if err := s.outbox.Send([]string{release.formatGameRecord(&release.Games[k], "")}); err != nil {
+				return err
+			}
+			for _, marker := range s.outbox.Take() {
+				marker.Delivered(now)
+				notifications = append(notifications, marker)
+			}
+		}
+		return tx.EnqueueNotifications(notifications)
+	})
+}
+
+// bulkReleaseFormat formats the grouped message about games of the bulk release in the given locale
+func bulkReleaseFormat(games []entity.Game) entity.LocalizedParts {
+	release := &Schedule{Games: games}
+	return func(locale i18n.Locale) []string {
+		release.Locale = locale
+		return release.formatList(i18n.T(locale, "bulk_release.header", len(games)))
+	}
+}
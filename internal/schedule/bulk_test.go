@@ -0,0 +1,91 @@
+package schedule
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kettari/location-bot/internal/entity"
+)
+
+func TestSchedule_markBulkRelease(t *testing.T) {
+	page := func(url string, count int) []entity.Game {
+		var games []entity.Game
+		for k := 0; k < count; k++ {
+			games = append(games, entity.Game{
+				ExternalID: fmt.Sprintf("%s-%d", url, k),
+				URL:        url,
+				Date:       time.Now().Add(24 * time.Hour),
+				Joinable:   true,
+				SeatsTotal: 5,
+				SeatsFree:  5,
+			})
+		}
+		return games
+	}
+	released := func(s *Schedule) map[string]int {
+		result := map[string]int{}
+		for _, game := range s.Games {
+			if game.BulkRelease {
+				result[game.URL]++
+			}
+		}
+		return result
+	}
+
+	tests := []struct {
+		name      string
+		run, page int
+		want      map[string]int
+	}{
+		{"disabled", 0, 0, map[string]int{}},
+		{"below limits", 10, 5, map[string]int{}},
+		{"festival page", 10, 3, map[string]int{"festival": 4}},
+		{"whole run", 6, 0, map[string]int{"festival": 4, "club": 1, "other": 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSchedule(nil)
+			s.Add(page("festival", 4)...)
+			s.Add(page("club", 1)...)
+			s.Add(page("other", 2)...)
+			// Full game is not announced as new, so it does not count
+			s.Add(entity.Game{ExternalID: "full", URL: "festival", Date: time.Now().Add(time.Hour), Joinable: true, SeatsTotal: 5})
+			s.UseBulkRelease(tt.run, tt.page)
+
+			if err := s.markBulkRelease(nil); err != nil {
+				t.Fatal(err)
+			}
+			got := released(s)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("bulk release = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchedule_markBulkRelease_LinkedGames(t *testing.T) {
+	s := NewSchedule(nil)
+	// Festival program page links every game to its own URL
+	for k := 0; k < 4; k++ {
+		s.Add(entity.Game{
+			ExternalID: fmt.Sprintf("festival-%d", k),
+			URL:        fmt.Sprintf("https://rolecon.ru/game/%d", k),
+			PageURL:    "https://rolecon.ru/event/festival",
+			Date:       time.Now().Add(24 * time.Hour),
+			Joinable:   true,
+			SeatsTotal: 5,
+			SeatsFree:  5,
+		})
+	}
+	s.UseBulkRelease(0, 3)
+
+	if err := s.markBulkRelease(nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, game := range s.Games {
+		if !game.BulkRelease {
+			t.Errorf("game %s of the festival page is not in the bulk release", game.ExternalID)
+		}
+	}
+}
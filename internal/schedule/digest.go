@@ -0,0 +1,227 @@
+package schedule
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/kettari/location-bot/internal/config"
+	"github.com/kettari/location-bot/internal/entity"
+	"github.com/kettari/location-bot/internal/i18n"
+)
+
+// Digest is what changed in the schedule during the period and the open games of the upcoming weekend
+type Digest struct {
+	Period entity.DigestPeriod
+	// Since and Until bound the summarized history, Since is not included
+	Since, Until time.Time
+	New          []entity.Game
+	Filled       []entity.Game
+	Reopened     []entity.Game
+	Cancelled    []entity.Game
+	Weekend      []entity.Game
+}
+
+// Empty returns true if the digest has nothing to tell
+func (d *Digest) Empty() bool {
+	return len(d.New)+len(d.Filled)+len(d.Reopened)+len(d.Cancelled)+len(d.Weekend) == 0
+}
+
+// LoadDigest collects events of the period recorded in the outbox after the last digest of the period,
+// or during the period length before until if no digest was sent yet. Each game is listed by its current state:
+// cancelled game is not new, re-opened game is still joinable, filled game has no free seats left
+func (s *Schedule) LoadDigest(period entity.DigestPeriod, until time.Time) (*Digest, error) {
+	if s.manager == nil {
+		return nil, errors.New("manager not initialized")
+	}
+
+	digest := &Digest{Period: period, Since: until.Add(-period.Duration()), Until: until}
+	last, err := s.manager.LastDigest(period)
+	if err != nil {
+		return nil, err
+	}
+	if last != nil {
+		digest.Since = last.Until
+	}
+
+	cancelled, err := s.manager.GamesWithEvents([]entity.SubjectType{entity.SubjectTypeCancelled}, digest.Since, until)
+	if err != nil {
+		return nil, err
+	}
+	isCancelled := make(map[uint]bool)
+	for _, game := range cancelled {
+		if !game.Joinable {
+			digest.Cancelled = append(digest.Cancelled, game)
+			isCancelled[game.ID] = true
+		}
+	}
+
+	created, err := s.manager.GamesWithEvents([]entity.SubjectType{entity.SubjectTypeNew, entity.SubjectTypeBulkRelease}, digest.Since, until)
+	if err != nil {
+		return nil, err
+	}
+	isNew := make(map[uint]bool)
+	for _, game := range created {
+		if !isCancelled[game.ID] {
+			digest.New = append(digest.New, game)
+			isNew[game.ID] = true
+		}
+	}
+
+	reopened, err := s.manager.GamesWithEvents([]entity.SubjectType{entity.SubjectTypeBecomeJoinable, entity.SubjectTypeFreeSeatsAdded}, digest.Since, until)
+	if err != nil {
+		return nil, err
+	}
+	for _, game := range reopened {
+		if !isNew[game.ID] && game.Joinable && game.SeatsFree > 0 {
+			digest.Reopened = append(digest.Reopened, game)
+		}
+	}
+
+	changed, err := s.manager.GamesWithEvents([]entity.SubjectType{entity.SubjectTypeSeatsChanged}, digest.Since, until)
+	if err != nil {
+		return nil, err
+	}
+	for _, game := range changed {
+		if !isCancelled[game.ID] && game.SeatsTotal > 0 && game.SeatsFree == 0 {
+			digest.Filled = append(digest.Filled, game)
+		}
+	}
+
+	from, to, err := s.weekend(until)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.eventsQuery(EventFilter{}).
+		Where("date >= ? AND date < ?", from, to).
+		Order("date ASC").
+		Find(&digest.Weekend).Error; err != nil {
+		return nil, err
+	}
+
+	slog.Debug("digest loaded",
+		"period", period,
+		"since", digest.Since,
+		"new_count", len(digest.New),
+		"filled_count", len(digest.Filled),
+		"reopened_count", len(digest.Reopened),
+		"cancelled_count", len(digest.Cancelled),
+		"weekend_count", len(digest.Weekend))
+
+	return digest, nil
+}
+
+// weekend returns bounds of the upcoming weekend in the schedule time zone; on Saturday and Sunday
+// it is the current one
+func (s *Schedule) weekend(moment time.Time) (from, to time.Time, err error) {
+	location, err := s.location()
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	local := moment.In(location)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+	switch local.Weekday() {
+	case time.Saturday:
+		from = today
+	case time.Sunday:
+		from = today.AddDate(0, 0, -1)
+	default:
+		from = today.AddDate(0, 0, int(time.Saturday-local.Weekday()))
+	}
+	return from, from.AddDate(0, 0, 2), nil
+}
+
+// FormatDigest returns the digest as a message list: header with the period and a section per kind of change,
+// games of the section are grouped by date. Sections without games are skipped
+func (s *Schedule) FormatDigest(digest *Digest) ([]string, error) {
+	location, err := s.location()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	slice := i18n.T(s.Locale, "digest.title."+string(digest.Period),
+		digest.Since.In(location).Format("02.01 15:04"),
+		digest.Until.In(location).Format("02.01 15:04"))
+	if digest.Empty() {
+		return []string{slice + "\n\n" + i18n.T(s.Locale, "digest.empty")}, nil
+	}
+
+	sections := []struct {
+		key   string
+		games []entity.Game
+	}{
+		{"digest.section.new", digest.New},
+		{"digest.section.reopened", digest.Reopened},
+		{"digest.section.filled", digest.Filled},
+		{"digest.section.cancelled", digest.Cancelled},
+		{"digest.section.weekend", digest.Weekend},
+	}
+	for _, section := range sections {
+		if len(section.games) == 0 {
+			continue
+		}
+		sortGames(section.games)
+		slice += "\n\n" + i18n.T(s.Locale, section.key, len(section.games))
+
+		currentDate := ""
+		for k := range section.games {
+			game := &section.games[k]
+			gameDate := s.formatGameDate(game)
+			if currentDate != gameDate {
+				currentDate = gameDate
+				slice += "\n" + gameDate
+			}
+			slice += "\n" + s.formatGameRecord(game, "")
+
+			if len(slice) > 4000 {
+				result = append(result, slice)
+				slice = ""
+			}
+		}
+	}
+	if len(strings.Trim(slice, " \n\r\t")) > 0 {
+		result = append(result, slice)
+	}
+
+	return result, nil
+}
+
+// ExecuteDigest sends the digest of the period to recipients and remembers its end, so the next digest
+// of the period starts where this one stopped. Failed digest is not remembered and is repeated by the next run
+//
+// Destination format: chat_id_1,thread_id_1;chat_id_2,thread_id_2
+func (s *Schedule) ExecuteDigest(period entity.DigestPeriod, destination string) error {
+	slog.Info("executing digest", "period", period)
+
+	digest, err := s.LoadDigest(period, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if config.GetConfig().DryRun {
+		parts, err := s.FormatDigest(digest)
+		if err != nil {
+			return err
+		}
+		slog.Info("DRY RUN MODE: skipping digest sending", "period", period, "messages_count", len(parts))
+		for _, part := range parts {
+			slog.Debug("DRY RUN: would send digest", "text", part)
+		}
+		return nil
+	}
+
+	if err = s.sendReport(destination, func() ([]string, error) {
+		return s.FormatDigest(digest)
+	}); err != nil {
+		return err
+	}
+	if err = s.manager.SaveDigest(&entity.Digest{Period: period, Until: digest.Until}); err != nil {
+		return err
+	}
+
+	slog.Info("digest sent", "period", period, "since", digest.Since, "until", digest.Until)
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kettari/location-bot/internal/entity"
+)
+
+func TestSchedule_weekend(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	saturday := time.Date(2025, 11, 8, 0, 0, 0, 0, moscow)
+
+	for _, moment := range []time.Time{
+		time.Date(2025, 11, 3, 9, 0, 0, 0, moscow),
+		time.Date(2025, 11, 7, 23, 59, 0, 0, moscow),
+		time.Date(2025, 11, 8, 12, 0, 0, 0, moscow),
+		time.Date(2025, 11, 9, 22, 0, 0, 0, moscow),
+		// Friday evening in Moscow, but still Friday in UTC
+		time.Date(2025, 11, 7, 21, 30, 0, 0, time.UTC),
+	} {
+		from, to, err := NewSchedule(nil).weekend(moment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !from.Equal(saturday) || !to.Equal(saturday.AddDate(0, 0, 2)) {
+			t.Errorf("weekend(%v) = %v - %v, want from %v", moment, from, to, saturday)
+		}
+	}
+}
+
+func TestSchedule_FormatDigest(t *testing.T) {
+	since := time.Date(2025, 10, 30, 7, 0, 0, 0, time.UTC)
+	until := since.Add(24 * time.Hour)
+
+	t.Run("empty", func(t *testing.T) {
+		messages, err := NewSchedule(nil).FormatDigest(&Digest{Period: entity.DigestPeriodDay, Since: since, Until: until})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(messages) != 1 || !strings.Contains(messages[0], "(30.10 10:00 – 31.10 10:00)") || !strings.Contains(messages[0], "Изменений нет") {
+			t.Errorf("unexpected empty digest: %q", messages)
+		}
+	})
+
+	t.Run("sections", func(t *testing.T) {
+		games := boardGames(3)
+		games[2].SeatsFree = 0
+		digest := &Digest{
+			Period: entity.DigestPeriodWeek,
+			Since:  since,
+			Until:  until,
+			New:    games[:2],
+			Filled: games[2:],
+		}
+		messages, err := NewSchedule(nil).FormatDigest(digest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		text := strings.Join(messages, "\n")
+		if !strings.Contains(text, "Расписание за неделю") || !strings.Contains(text, "Новые игры (2)") || !strings.Contains(text, "Мест не осталось (1)") {
+			t.Errorf("missing sections: %q", text)
+		}
+		if strings.Contains(text, "Отменены") || strings.Contains(text, "Изменений нет") {
+			t.Errorf("empty sections must be skipped: %q", text)
+		}
+		if strings.Index(text, "Новые игры") > strings.Index(text, "Мест не осталось") {
+			t.Errorf("sections out of order: %q", text)
+		}
+	})
+}
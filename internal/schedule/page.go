@@ -0,0 +1,68 @@
+package schedule
+
+import (
+	"time"
+
+	"github.com/kettari/location-bot/internal/templates"
+)
+
+// dayMessageLimit leaves room for the header below the Telegram 4096 characters limit
+const dayMessageLimit = 3800
+
+// Days returns distinct days of the loaded games in the schedule time zone, ascending
+func (s *Schedule) Days() ([]time.Time, error) {
+	location, err := s.location()
+	if err != nil {
+		return nil, err
+	}
+
+	s.sortGames()
+
+	var days []time.Time
+	for _, game := range s.Games {
+		date := game.Date.In(location)
+		day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, location)
+		if len(days) == 0 || !days[len(days)-1].Equal(day) {
+			days = append(days, day)
+		}
+	}
+
+	return days, nil
+}
+
+// FormatDay returns the loaded games of the day as a single message.
+// Each game links to its card in the bot; games which do not fit into the message are only counted
+func (s *Schedule) FormatDay(day time.Time, botUsername string) (string, error) {
+	location, err := s.location()
+	if err != nil {
+		return "", err
+	}
+
+	s.sortGames()
+
+	result := ""
+	currentDate := ""
+	skipped := 0
+	for _, game := range s.Games {
+		date := game.Date.In(location)
+		if date.Year() != day.Year() || date.YearDay() != day.YearDay() {
+			continue
+		}
+		if skipped > 0 || len(result) > dayMessageLimit {
+			skipped++
+			continue
+		}
+
+		gameDate := s.formatGameDate(&game)
+		if currentDate != gameDate {
+			currentDate = gameDate
+			result += "\n\n" + gameDate
+		}
+		result += "\n" + s.formatGameRecord(&game, game.CardURL(botUsername))
+	}
+	if skipped > 0 {
+		result += "\n\n" + templates.Render(s.Locale, templates.ScheduleMore, skipped)
+	}
+
+	return result, nil
+}
@@ -1,10 +1,13 @@
 package schedule
 
 import (
+	"errors"
 	"log/slog"
+	"time"
 
 	"github.com/kettari/location-bot/internal/bot"
 	"github.com/kettari/location-bot/internal/config"
+	"github.com/kettari/location-bot/internal/entity"
 )
 
 // ExecuteFullReport and send notification to recipients
@@ -13,23 +16,88 @@ import (
 func (s *Schedule) ExecuteFullReport(destination string) error {
 	slog.Info("executing joinable games full report")
 
-	conf := config.GetConfig()
-	b, err := bot.CreateBot(conf.BotToken, destination)
-	if err != nil {
-		slog.Error("unable to create bot processor object", "error", err)
+	if err := s.sendReport(destination, s.Format); err != nil {
 		return err
 	}
-	notification, err := s.Format()
-	if err != nil {
-		slog.Error("unable to format notification", "error", err)
-		return err
+
+	slog.Info("full report sent")
+
+	return nil
+}
+
+// sendReport sends messages built by format to recipients, formatted in the locale of each chat
+func (s *Schedule) sendReport(destination string, format func() ([]string, error)) error {
+	conf := config.GetConfig()
+	for _, locale := range conf.RecipientLocales(destination) {
+		b, err := bot.CreateBot(conf.BotToken, conf.RecipientsIn(destination, locale), s.limiter)
+		if err != nil {
+			slog.Error("unable to create bot processor object", "error", err)
+			return err
+		}
+		s.Locale = locale
+		notification, err := format()
+		if err != nil {
+			slog.Error("unable to format notification", "error", err)
+			return err
+		}
+		if err = b.Send(notification); err != nil {
+			slog.Error("unable to send notification", "locale", locale, "error", err)
+			return err
+		}
 	}
-	if err = b.Send(notification); err != nil {
-		slog.Error("unable to send notification", "error", err)
-		return err
+	return nil
+}
+
+// ExecuteUnnotifiedReport sends games loaded by [Schedule.LoadUnnotifiedEvents] to the recipients which missed them
+// and records them as announced to each recipient, so the next report skips them. With markOnly the games
+// are recorded without sending, e.g. to start tracking on the database filled before notifications were recorded
+func (s *Schedule) ExecuteUnnotifiedReport(markOnly bool) error {
+	slog.Info("executing unnotified games report", "games_count", len(s.Games), "recipients_count", len(s.recipients), "mark_only", markOnly)
+	if len(s.Games) == 0 {
+		slog.Info("no unnotified games, nothing to report")
+		return nil
+	}
+	if s.manager == nil {
+		return errors.New("manager not initialized")
 	}
 
-	slog.Info("full report sent")
+	conf := config.GetConfig()
+	if conf.DryRun {
+		slog.Info("DRY RUN MODE: skipping unnotified games report")
+		return nil
+	}
+
+	games := s.Games
+	defer func() { s.Games = games }()
+	for _, recipient := range s.recipients {
+		s.Games = s.unnotified[recipient]
+		if !markOnly {
+			if err := s.sendReport(recipient, s.Format); err != nil {
+				return err
+			}
+		}
+
+		// Record every reported game as delivered announcement to the recipient, so a failure
+		// of the next recipient does not repeat the report in this one
+		now := time.Now()
+		locale := conf.ChatLocale(recipientChatID(recipient))
+		outbox := entity.NewOutboxRecorder()
+		for k := range s.Games {
+			outbox.Begin(&s.Games[k], entity.SubjectTypeReport, now)
+			if err := outbox.SendToRecipients(recipient, locale, []string{s.formatGameRecord(&s.Games[k], "")}); err != nil {
+				return err
+			}
+		}
+		notifications := outbox.Take()
+		for k := range notifications {
+			notifications[k].Delivered(now)
+		}
+		if err := s.manager.EnqueueNotifications(notifications); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("unnotified games report sent")
 
 	return nil
 }
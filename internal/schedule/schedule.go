@@ -1,27 +1,84 @@
 package schedule
 
 import (
+	"context"
 	"errors"
-	"fmt"
 	"log/slog"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/kettari/location-bot/internal/bot"
 	"github.com/kettari/location-bot/internal/config"
 	"github.com/kettari/location-bot/internal/entity"
+	"github.com/kettari/location-bot/internal/i18n"
 	"github.com/kettari/location-bot/internal/storage"
+	"github.com/kettari/location-bot/internal/templates"
 	"gorm.io/gorm"
 )
 
+var (
+	ErrGameNotFound  = errors.New("game not found")
+	ErrGameAmbiguous = errors.New("several games match the reference")
+)
+
+// EventFilter narrows down games loaded from the database; zero value selects all future joinable games
+type EventFilter struct {
+	// Weekday is ISO day of week in the schedule time zone, 1 is Monday; zero means any day
+	Weekday int
+	// System is exact game system; empty means any system
+	System string
+	// IncludeFull adds future games without free seats
+	IncludeFull bool
+}
+
 type Schedule struct {
 	manager *storage.Manager
+	outbox  *entity.OutboxRecorder
+	// limiter is shared by bots of the schedule, see [Schedule.UseLimiter]
+	limiter *bot.Limiter
 	Games   []entity.Game `json:"games"`
+	// Locale of the formatted messages
+	Locale i18n.Locale `json:"-"`
+	// Location is the time zone of days and dates in the formatted messages; nil means [templates.DefaultTimezone]
+	Location *time.Location `json:"-"`
+	// bulkRun and bulkPage are limits of new games in the run and on one event page, see [Schedule.UseBulkRelease]
+	bulkRun  int
+	bulkPage int
+	// anomalous is set by CheckAbsentGames if the run tripped [entity.AbsenceGuard]
+	anomalous bool
+	// cancelled are external IDs of absent games cancelled by this run
+	cancelled []string
+	// unchecked are external IDs of stored games from event pages not parsed in the run, they are not absent
+	unchecked []string
+	// unnotified are games loaded by LoadUnnotifiedEvents by the recipient which was not told about them,
+	// recipients keep their order
+	unnotified map[string][]entity.Game
+	recipients []string
 }
 
 func NewSchedule(manager *storage.Manager) *Schedule {
-	return &Schedule{manager: manager}
+	return &Schedule{manager: manager, limiter: bot.NewLimiter(), Locale: i18n.Default}
+}
+
+// UseLimiter makes bots of the schedule share Telegram rate limits with other bots of the process
+func (s *Schedule) UseLimiter(limiter *bot.Limiter) {
+	s.limiter = limiter
+}
+
+// UseOutbox makes SaveGames and CheckAbsentGames save notifications of observers registered with the recorder
+// in the same transaction as the game; notifications are sent later by the notifications:deliver worker
+func (s *Schedule) UseOutbox(outbox *entity.OutboxRecorder) {
+	s.outbox = outbox
+}
+
+// UseBulkRelease makes SaveGames announce new games by one grouped message in the [Schedule.Format] style
+// instead of a message per game if there are more than run new games in total or more than page new games
+// on one event page, e.g. a festival program. Zero disables the limit
+func (s *Schedule) UseBulkRelease(run, page int) {
+	s.bulkRun = run
+	s.bulkPage = page
 }
 
 func (s *Schedule) Add(games ...entity.Game) {
@@ -29,69 +86,32 @@ func (s *Schedule) Add(games ...entity.Game) {
 }
 
 // Format returns a formatted message list for games.
-// If no games are available, returns ["Открытых игр для записи на сайте нет."]
+// If no games are available, returns the [templates.ScheduleEmpty] message
 func (s *Schedule) Format() ([]string, error) {
-	var result []string
-
 	// If no games, return specific message
 	if len(s.Games) == 0 {
-		return []string{"Открытых игр для записи на сайте нет."}, nil
+		return []string{templates.Render(s.Locale, templates.ScheduleEmpty, nil)}, nil
 	}
 
-	// Sort games: by date (ascending), then by free seats (descending), then by title (ascending)
-	sort.Slice(s.Games, func(i, j int) bool {
-		// First: sort by date ascending
-		if s.Games[i].Date.Before(s.Games[j].Date) {
-			return true
-		}
-		if s.Games[i].Date.After(s.Games[j].Date) {
-			return false
-		}
-
-		// Second: if same time, sort by free seats descending (most free first)
-		if s.Games[i].SeatsFree != s.Games[j].SeatsFree {
-			return s.Games[i].SeatsFree > s.Games[j].SeatsFree
-		}
+	return s.formatList(templates.Render(s.Locale, templates.ScheduleHeader, nil)), nil
+}
 
-		// Third: if same free seats, sort by title ascending
-		return s.Games[i].Title < s.Games[j].Title
-	})
+// formatList returns the games under the header grouped by date and split into messages
+func (s *Schedule) formatList(header string) []string {
+	var result []string
 
-	dow := map[string]string{
-		"Mon": "ПОНЕДЕЛЬНИК",
-		"Tue": "ВТОРНИК",
-		"Wed": "СРЕДА",
-		"Thu": "ЧЕТВЕРГ",
-		"Fri": "ПЯТНИЦА",
-		"Sat": "СУББОТА",
-		"Sun": "ВОСКРЕСЕНЬЕ",
-	}
+	s.sortGames()
 
 	currentDate := ""
-	moscow, err := time.LoadLocation("Europe/Moscow")
-	if err != nil {
-		return result, err
-	}
-	slice := "Игры, на которые можно записаться:"
+	slice := header
 	for _, game := range s.Games {
-		gameDate := fmt.Sprintf("<b>%s</b> (%s, %s)",
-			dow[game.Date.In(moscow).Format("Mon")],
-			game.Date.In(moscow).Format("02.01"),
-			game.Date.In(moscow).Format("15:04"))
+		gameDate := s.formatGameDate(&game)
 		if currentDate != gameDate {
 			currentDate = gameDate
 			slice += "\n\n" + gameDate
 		}
-		record := fmt.Sprintf("🔸 %d/%d <a href=\"%s\">%s</a> [%s; %s]",
-			game.SeatsFree,
-			game.SeatsTotal,
-			game.URL,
-			game.Title,
-			game.System,
-			game.Setting,
-		)
 
-		slice += "\n" + record
+		slice += "\n" + s.formatGameRecord(&game, "")
 
 		if len(slice) > 4000 {
 			result = append(result, slice)
@@ -102,11 +122,61 @@ func (s *Schedule) Format() ([]string, error) {
 		result = append(result, slice)
 	}
 
-	return result, nil
+	return result
+}
+
+// sortGames by date (ascending), then by free seats (descending), then by title (ascending)
+func (s *Schedule) sortGames() {
+	sortGames(s.Games)
+}
+
+// sortGames sorts the games like [Schedule.sortGames]
+func sortGames(games []entity.Game) {
+	sort.Slice(games, func(i, j int) bool {
+		// First: sort by date ascending
+		if games[i].Date.Before(games[j].Date) {
+			return true
+		}
+		if games[i].Date.After(games[j].Date) {
+			return false
+		}
+
+		// Second: if same time, sort by free seats descending (most free first)
+		if games[i].SeatsFree != games[j].SeatsFree {
+			return games[i].SeatsFree > games[j].SeatsFree
+		}
+
+		// Third: if same free seats, sort by title ascending
+		return games[i].Title < games[j].Title
+	})
+}
+
+// location returns the time zone of the formatted messages
+func (s *Schedule) location() (*time.Location, error) {
+	if s.Location != nil {
+		return s.Location, nil
+	}
+	return templates.LoadLocation(templates.DefaultTimezone)
+}
+
+// formatGameDate returns header of the games list with weekday, date and time of the game start
+func (s *Schedule) formatGameDate(game *entity.Game) string {
+	return templates.RenderIn(s.Locale, s.Location, templates.ScheduleDate, game)
+}
+
+// gameRecord is the data of [templates.ScheduleGame]
+type gameRecord struct {
+	Game    *entity.Game
+	CardURL string
+}
+
+// formatGameRecord returns single line of the games list; non-empty cardURL adds link to the game card
+func (s *Schedule) formatGameRecord(game *entity.Game, cardURL string) string {
+	return templates.RenderIn(s.Locale, s.Location, templates.ScheduleGame, gameRecord{Game: game, CardURL: cardURL})
 }
 
-// LoadJoinableEvents loads future joinable games
-func (s *Schedule) LoadJoinableEvents() error {
+// LoadJoinableEvents loads future joinable games narrowed down by the filter
+func (s *Schedule) LoadJoinableEvents(filter EventFilter) error {
 	if s.manager == nil {
 		return errors.New("manager not initialized")
 	}
@@ -114,11 +184,12 @@ func (s *Schedule) LoadJoinableEvents() error {
 	if err := s.manager.Connect(); err != nil {
 		return err
 	}
-	if result := s.manager.DB().
-		Where(&entity.Game{Joinable: true}).
-		Where("date > ?", time.Now()).
-		Order("date ASC").
-		Find(&s.Games); result.Error != nil {
+
+	query := s.eventsQuery(filter)
+	if len(filter.System) > 0 {
+		query = query.Where(&entity.Game{System: filter.System})
+	}
+	if result := query.Order("date ASC").Find(&s.Games); result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			slog.Warn("no joinable future games found, exiting")
 			return nil
@@ -130,8 +201,61 @@ func (s *Schedule) LoadJoinableEvents() error {
 	return nil
 }
 
-// LoadUnnotifiedEvents loads future joinable games which were not notified about
-func (s *Schedule) LoadUnnotifiedEvents() error {
+// JoinableSystems returns sorted distinct game systems of future joinable games; the filter system is ignored
+func (s *Schedule) JoinableSystems(filter EventFilter) ([]string, error) {
+	if s.manager == nil {
+		return nil, errors.New("manager not initialized")
+	}
+
+	if err := s.manager.Connect(); err != nil {
+		return nil, err
+	}
+
+	var systems []string
+	if result := s.eventsQuery(filter).
+		Where("system <> ''").
+		Distinct("system").
+		Order("system ASC").
+		Pluck("system", &systems); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return systems, nil
+}
+
+// eventsQuery builds query for future games matching the filter except the system
+func (s *Schedule) eventsQuery(filter EventFilter) *gorm.DB {
+	query := s.manager.DB().Model(&entity.Game{}).Where("date > ?", time.Now())
+	if filter.IncludeFull {
+		// Full games keep zero free seats, cancelled ones keep free seats but are not joinable
+		query = query.Where("joinable = ? OR (seats_total > 0 AND seats_free = 0)", true)
+	} else {
+		query = query.Where(&entity.Game{Joinable: true})
+	}
+	if filter.Weekday > 0 {
+		timezone := templates.DefaultTimezone
+		if s.Location != nil {
+			timezone = s.Location.String()
+		}
+		query = query.Where("EXTRACT(ISODOW FROM date AT TIME ZONE ?) = ?", timezone, filter.Weekday)
+	}
+	return query
+}
+
+// announcementSubjects are events which tell notification chats the game is open for joining
+var announcementSubjects = []entity.SubjectType{
+	entity.SubjectTypeNew,
+	entity.SubjectTypeBecomeJoinable,
+	entity.SubjectTypeReport,
+	entity.SubjectTypeBulkRelease,
+}
+
+// LoadUnnotifiedEvents loads future joinable games and finds recipients "chat_id,thread_id" which were not told
+// about them: chats of the route destination of the new game, destination if it is not routed, without delivered
+// or pending announcement in the chat locale. Dead-lettered notifications do not count
+//
+// Destination format: chat_id_1,thread_id_1;chat_id_2,thread_id_2
+func (s *Schedule) LoadUnnotifiedEvents(routes entity.Routes, destination string) error {
 	if s.manager == nil {
 		return errors.New("manager not initialized")
 	}
@@ -139,38 +263,106 @@ func (s *Schedule) LoadUnnotifiedEvents() error {
 	if err := s.manager.Connect(); err != nil {
 		return err
 	}
+	var games []entity.Game
 	if result := s.manager.DB().
 		Where(&entity.Game{Joinable: true}).
-		Where("notification_sent = ?", false).
 		Where("date > ?", time.Now()).
 		Order("date ASC").
-		Find(&s.Games); result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			slog.Info("no joinable unnotified games found, exiting")
-			return nil
-		}
+		Find(&games); result.Error != nil {
 		return result.Error
 	}
-	slog.Debug("found joinable unnotified games", "games_count", len(s.Games))
+	if len(games) == 0 {
+		slog.Info("no joinable games found, exiting")
+		return nil
+	}
+	gameIDs := make([]uint, len(games))
+	for k := range games {
+		gameIDs[k] = games[k].ID
+	}
+	var announcements []entity.Notification
+	if result := s.manager.DB().
+		Select("game_id", "chat_id", "destination", "locale").
+		Where("game_id IN ?", gameIDs).
+		Where("chat_id = ?", 0).
+		Where("subject IN ?", announcementSubjects).
+		Where("status IN ?", []entity.NotificationStatus{
+			entity.NotificationStatusPending,
+			entity.NotificationStatusDelivered,
+		}).
+		Find(&announcements); result.Error != nil {
+		return result.Error
+	}
+	announced := map[uint][]entity.Notification{}
+	for _, notification := range announcements {
+		announced[notification.GameID] = append(announced[notification.GameID], notification)
+	}
+
+	conf := config.GetConfig()
+	s.Games = nil
+	s.unnotified = map[string][]entity.Game{}
+	s.recipients = nil
+	for _, game := range games {
+		expected := routes.Destination(&game, entity.SubjectTypeNew)
+		if len(expected) == 0 {
+			expected = destination
+		}
+		var missed bool
+		for _, recipient := range strings.Split(expected, ";") {
+			recipient = strings.TrimSpace(recipient)
+			if len(recipient) == 0 || reaches(announced[game.ID], recipient, destination, conf.ChatLocale(recipientChatID(recipient))) {
+				continue
+			}
+			if _, ok := s.unnotified[recipient]; !ok {
+				s.recipients = append(s.recipients, recipient)
+			}
+			s.unnotified[recipient] = append(s.unnotified[recipient], game)
+			missed = true
+		}
+		if missed {
+			s.Games = append(s.Games, game)
+		}
+	}
+	slog.Debug("found joinable unnotified games", "games_count", len(s.Games), "recipients_count", len(s.recipients))
 
 	return nil
 }
 
-func (s *Schedule) CheckAbsentGames() error {
+// reaches returns true if any of the notifications goes to the recipient in the locale
+func reaches(notifications []entity.Notification, recipient, destination string, locale i18n.Locale) bool {
+	for k := range notifications {
+		if notifications[k].Reaches(recipient, destination, locale) {
+			return true
+		}
+	}
+	return false
+}
+
+// recipientChatID returns chat of the recipient "chat_id,thread_id"
+func recipientChatID(recipient string) int64 {
+	chat, _, _ := strings.Cut(recipient, ",")
+	chatID, _ := strconv.ParseInt(strings.TrimSpace(chat), 10, 64)
+	return chatID
+}
+
+// CheckAbsentGames cancels stored future games absent from the schedule. Games of failedURLs, event pages
+// failed to fetch in this run, are not checked: their absence means nothing. Games of unchangedURLs, event pages
+// not parsed since they did not change, are present and count as parsed by [entity.AbsenceGuard]
+func (s *Schedule) CheckAbsentGames(ctx context.Context, failedURLs, unchangedURLs []string) error {
 	conf := config.GetConfig()
+	manager := s.manager.WithContext(ctx)
 
-	if conf.DryRun && s.manager == nil {
+	if conf.DryRun && manager == nil {
 		slog.Info("DRY RUN MODE: skipping check for absent games")
 		return nil
 	}
 
-	if s.manager == nil {
+	if manager == nil {
 		return errors.New("manager not initialized")
 	}
 
 	// Check for absent games
 	var storedGames []entity.Game
-	if result := s.manager.DB().
+	if result := manager.DB().
 		Where(&entity.Game{Joinable: true}).
 		Where("date > ?", time.Now()).
 		Order("date ASC").
@@ -181,51 +373,133 @@ func (s *Schedule) CheckAbsentGames() error {
 		}
 		return result.Error
 	}
+	storedGames = s.skipPages(storedGames, failedURLs)
+	absentGames, parsed := s.absentGames(storedGames, unchangedURLs)
+	if reason := conf.AbsenceGuard.Check(parsed, len(storedGames), len(absentGames)); len(reason) > 0 {
+		return s.reportAnomaly(manager, reason, parsed, len(storedGames), len(absentGames))
+	}
+
 	// Register observers
-	b, err := bot.CreateBot(conf.BotToken, conf.NotificationChatID)
-	if err != nil {
-		slog.Error("unable to create bot processor object", "error", err)
-		return err
+	var b entity.MessageDispatcher = s.outbox
+	if s.outbox == nil {
+		var err error
+		if b, err = bot.CreateBot(conf.BotToken, conf.NotificationChatID, s.limiter); err != nil {
+			slog.Error("unable to create bot processor object", "error", err)
+			return err
+		}
 	}
-	for k := range storedGames {
-		storedGames[k].Register(entity.CancelledGameObserver(b))
+	observers := []entity.Observer{entity.CancelledGameObserver(b)}
+	if s.outbox != nil {
+		observers = append(observers, entity.AnnouncementGameObserver(s.outbox))
+	}
+	for k := range absentGames {
+		for _, observer := range observers {
+			absentGames[k].Register(observer)
+		}
 	}
 
 	if conf.DryRun {
 		slog.Info("DRY RUN MODE: skipping database updates for absent games")
 	}
 
-	for _, sg := range storedGames {
-		found := false
-		for _, jg := range s.Games {
-			if jg.ExternalID == sg.ExternalID {
-				found = true
-				break
-			}
+	for _, sg := range absentGames {
+		if err := ctx.Err(); err != nil {
+			return err
 		}
-		if !found {
-			slog.Warn("stored game is absent", "game_id", sg.ExternalID)
-			sg.Joinable = false
-			if !conf.DryRun {
-				if err := s.manager.DB().Save(&sg).Error; err != nil {
-					return err
-				}
+		// Game is cancelled only after it is absent from several consecutive runs
+		sg.AbsentRuns++
+		if sg.AbsentRuns < conf.AbsenceGuard.Runs {
+			slog.Warn("stored game is absent, waiting for the next runs", "game_id", sg.ExternalID, "absent_runs", sg.AbsentRuns)
+			if conf.DryRun {
+				continue
 			}
-			slog.Debug("cancelled game internals", "game", sg)
-			if sg.WasJoinable() {
-				sg.OnCancelled()
+			if err := manager.SaveAbsentRuns(&sg); err != nil {
+				return err
 			}
+			continue
 		}
+
+		slog.Warn("stored game is absent", "game_id", sg.ExternalID)
+		version := sg.UpdatedAt
+		sg.Joinable = false
+		slog.Debug("cancelled game internals", "game", sg)
+		var subjects []entity.SubjectType
+		if sg.WasJoinable() {
+			subjects = append(subjects, entity.SubjectTypeCancelled)
+		}
+		if conf.DryRun {
+			notify(&sg, subjects)
+			continue
+		}
+		if err := s.saveGame(manager, &sg, version, subjects); err != nil {
+			return err
+		}
+		s.cancelled = append(s.cancelled, sg.ExternalID)
 	}
 
 	return nil
 }
 
-func (s *Schedule) SaveGames() error {
+// absentGames returns stored games absent from the schedule and the number of parsed games. Games of
+// unchangedURLs are not parsed in the run with the page cache, so they are present and count as parsed
+func (s *Schedule) absentGames(storedGames []entity.Game, unchangedURLs []string) ([]entity.Game, int) {
+	present := make(map[string]bool, len(s.Games))
+	for _, game := range s.Games {
+		present[game.ExternalID] = true
+	}
+	unchanged := make(map[string]bool, len(unchangedURLs))
+	for _, url := range unchangedURLs {
+		unchanged[url] = true
+	}
+	parsed := len(s.Games)
+	var absent []entity.Game
+	for _, sg := range storedGames {
+		switch {
+		case present[sg.ExternalID]:
+		case unchanged[pageURL(&sg)]:
+			parsed++
+		default:
+			absent = append(absent, sg)
+		}
+	}
+	return absent, parsed
+}
+
+// skipPages returns stored games except those parsed from skippedURLs, which are remembered as unchecked
+func (s *Schedule) skipPages(storedGames []entity.Game, skippedURLs []string) []entity.Game {
+	if len(skippedURLs) == 0 {
+		return storedGames
+	}
+	skipped := make(map[string]bool, len(skippedURLs))
+	for _, url := range skippedURLs {
+		skipped[url] = true
+	}
+	var checked []entity.Game
+	for _, sg := range storedGames {
+		if skipped[pageURL(&sg)] {
+			s.unchecked = append(s.unchecked, sg.ExternalID)
+			continue
+		}
+		checked = append(checked, sg)
+	}
+	if len(s.unchecked) > 0 {
+		slog.Info("games of event pages failed to fetch are not checked for absence",
+			"games_count", len(s.unchecked),
+			"pages_count", len(skippedURLs))
+	}
+	return checked
+}
+
+// SaveGames stores parsed games and fires their events; cancelled ctx stops saving before the next game
+func (s *Schedule) SaveGames(ctx context.Context) error {
 	conf := config.GetConfig()
+	manager := s.manager.WithContext(ctx)
+	if err := s.markBulkRelease(manager); err != nil {
+		return err
+	}
 	if conf.DryRun {
 		slog.Info("DRY RUN MODE: skipping database saves")
-		if s.manager == nil {
+		if manager == nil {
 			// DryRun mode without DB - just simulate events
 			for _, game := range s.Games {
 				if game.NewJoinable() {
@@ -234,60 +508,207 @@ func (s *Schedule) SaveGames() error {
 					game.OnBecomeJoinable()
 				}
 			}
-			return nil
+			return s.announceBulkRelease()
 		}
 		// Still trigger observers for logging, but they won't send messages in DryRun
 		for _, game := range s.Games {
 			storedGame := game
-			result := s.manager.DB().Where(entity.Game{ExternalID: game.ExternalID}).First(&storedGame)
+			result := manager.DB().Where(entity.Game{ExternalID: game.ExternalID}).First(&storedGame)
 			if result.Error != nil && !errors.Is(result.Error, gorm.ErrRecordNotFound) {
 				return result.Error
 			}
 			freshGame := errors.Is(result.Error, gorm.ErrRecordNotFound)
 
-			if freshGame && game.NewJoinable() {
-				game.OnNew()
-			} else if game.FreeSeatsAdded(&storedGame) || game.BecomeJoinable(&storedGame) {
-				game.OnBecomeJoinable()
-			}
+			notify(&game, gameEvents(&game, &storedGame, freshGame))
 		}
 		return nil
 	}
 
-	if s.manager == nil {
+	if manager == nil {
 		return errors.New("manager not initialized")
 	}
 
 	// Save collection
-	for _, game := range s.Games {
+	for k := range s.Games {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		game := &s.Games[k]
+		if game.BulkRelease && s.outbox != nil {
+			// Saved together with the grouped message, see saveBulkRelease
+			continue
+		}
 		slog.Debug("saving the game", "game_external_id", game.ExternalID)
 
 		// Identify new games to fire event later
-		storedGame := game
-		result := s.manager.DB().Where(entity.Game{ExternalID: game.ExternalID}).First(&storedGame)
+		storedGame := *game
+		result := manager.DB().Where(entity.Game{ExternalID: game.ExternalID}).First(&storedGame)
 		if result.Error != nil && !errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return result.Error
 		}
 		freshGame := errors.Is(result.Error, gorm.ErrRecordNotFound)
 
-		// Find or create record for the game in the DB
-		result = s.manager.DB().Where(entity.Game{ExternalID: game.ExternalID}).FirstOrCreate(&storedGame)
-		if result.Error != nil {
-			return result.Error
+		// Fresh game has zero ID and is created by the save, together with its notifications
+		game.ID = storedGame.ID
+		if err := s.saveGame(manager, game, storedGame.UpdatedAt, gameEvents(game, &storedGame, freshGame)); err != nil {
+			return err
 		}
+	}
 
-		game.ID = storedGame.ID
-		if err := s.manager.DB().Save(&game).Error; err != nil {
+	return s.saveBulkRelease(manager)
+}
+
+// gameEvents selects events of the parsed game compared to its stored state
+func gameEvents(game, storedGame *entity.Game, freshGame bool) []entity.SubjectType {
+	var subjects []entity.SubjectType
+	if freshGame && game.NewJoinable() {
+		subjects = append(subjects, entity.SubjectTypeNew)
+	} else if game.FreeSeatsAdded(storedGame) || game.BecomeJoinable(storedGame) {
+		subjects = append(subjects, entity.SubjectTypeBecomeJoinable)
+	}
+	if !freshGame && game.FreeSeatsAdded(storedGame) {
+		subjects = append(subjects, entity.SubjectTypeFreeSeatsAdded)
+	}
+	if !freshGame && game.SeatsChanged(storedGame) {
+		subjects = append(subjects, entity.SubjectTypeSeatsChanged)
+	}
+	return subjects
+}
+
+// saveGame saves the game and fires its events. With outbox the notifications are saved in the same transaction,
+// so either both the game and its notifications are stored or neither is. Version is the update time
+// of the stored game state the events were detected against
+func (s *Schedule) saveGame(manager *storage.Manager, game *entity.Game, version time.Time, subjects []entity.SubjectType) error {
+	if s.outbox == nil {
+		if err := manager.DB().Save(game).Error; err != nil {
 			return err
 		}
+		notify(game, subjects)
+		return nil
+	}
+
+	return manager.Transaction(func(tx *storage.Manager) error {
+		if err := tx.DB().Save(game).Error; err != nil {
+			return err
+		}
+		// Drop notifications left by the rolled back transaction, if any
+		s.outbox.Take()
+		for _, subject := range subjects {
+			s.outbox.Begin(game, subject, version)
+			notify(game, []entity.SubjectType{subject})
+		}
+		return tx.EnqueueNotifications(s.outbox.Take())
+	})
+}
 
-		// Select event
-		if freshGame && game.NewJoinable() {
+// notify fires game events
+func notify(game *entity.Game, subjects []entity.SubjectType) {
+	for _, subject := range subjects {
+		switch subject {
+		case entity.SubjectTypeNew:
 			game.OnNew()
-		} else if game.FreeSeatsAdded(&storedGame) || game.BecomeJoinable(&storedGame) {
+		case entity.SubjectTypeBecomeJoinable:
 			game.OnBecomeJoinable()
+		case entity.SubjectTypeCancelled:
+			game.OnCancelled()
+		case entity.SubjectTypeFreeSeatsAdded:
+			game.OnFreeSeatsAdded()
+		case entity.SubjectTypeSeatsChanged:
+			game.OnSeatsChanged()
 		}
 	}
+}
+
+// FindGame finds stored game by internal ID, Rolecon URL or external ID
+func (s *Schedule) FindGame(reference string) (*entity.Game, error) {
+	if s.manager == nil {
+		return nil, errors.New("manager not initialized")
+	}
+	if err := s.manager.Connect(); err != nil {
+		return nil, err
+	}
+
+	reference = strings.TrimSpace(reference)
+	query := s.manager.DB().Model(&entity.Game{})
+	if id, err := strconv.ParseUint(reference, 10, 0); err == nil {
+		query = query.Where("id = ?", id)
+	} else if _, path, found := strings.Cut(reference, "rolecon.ru"); found {
+		query = query.Where("url = ?", "https://rolecon.ru"+strings.TrimSuffix(path, "/"))
+	} else {
+		query = query.Where(&entity.Game{ExternalID: reference})
+	}
+
+	var games []entity.Game
+	if result := query.Limit(2).Find(&games); result.Error != nil {
+		return nil, result.Error
+	}
+	switch len(games) {
+	case 0:
+		return nil, ErrGameNotFound
+	case 1:
+		return &games[0], nil
+	default:
+		return nil, ErrGameAmbiguous
+	}
+}
+
+// reportAnomaly skips cancellations of the run which looks like an outage or a layout change rather than
+// real cancellations: the run is recorded and the admin chat is alerted
+func (s *Schedule) reportAnomaly(manager *storage.Manager, reason entity.AnomalyReason, parsed, stored, absent int) error {
+	conf := config.GetConfig()
+	s.anomalous = true
+	slog.Error("absent games are not cancelled, the run looks anomalous",
+		"reason", reason,
+		"parsed_count", parsed,
+		"stored_count", stored,
+		"absent_count", absent)
+	if conf.DryRun {
+		slog.Info("DRY RUN MODE: skipping anomalous run record")
+		return nil
+	}
+
+	anomaly := &entity.FetchAnomaly{Reason: reason, ParsedCount: parsed, StoredCount: stored, AbsentCount: absent}
+	if len(conf.AdminChatID) > 0 {
+		text := i18n.T(conf.Locale, "anomaly.alert", i18n.T(conf.Locale, "anomaly.reason."+string(reason)), parsed, stored, absent)
+		b, err := bot.CreateBot(conf.BotToken, conf.AdminChatID, s.limiter)
+		if err == nil {
+			err = b.Send([]string{text})
+		}
+		if err != nil {
+			slog.Error("cannot alert the admin chat about anomalous run", "error", err)
+		} else {
+			anomaly.Alerted = true
+		}
+	}
+	return manager.CreateFetchAnomaly(anomaly)
+}
+
+// Anomalous returns true if CheckAbsentGames skipped cancellations since the run looks anomalous
+func (s *Schedule) Anomalous() bool {
+	return s.anomalous
+}
+
+// PurgeWatches removes watches of past games and of games cancelled by CheckAbsentGames in this run.
+// Other games keep their watches, whether they are full, waiting for the next runs to be cancelled
+// or on event pages not parsed in the run
+func (s *Schedule) PurgeWatches(ctx context.Context) error {
+	conf := config.GetConfig()
+	manager := s.manager.WithContext(ctx)
+	if conf.DryRun {
+		slog.Info("DRY RUN MODE: skipping watches purge")
+		return nil
+	}
+	if manager == nil {
+		return errors.New("manager not initialized")
+	}
+
+	purged, err := manager.PurgeWatches(s.cancelled)
+	if err != nil {
+		return err
+	}
+	if purged > 0 {
+		slog.Info("purged watches of past and cancelled games", "watches_count", purged)
+	}
 
 	return nil
 }
@@ -0,0 +1,71 @@
+package schedule
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/kettari/location-bot/internal/entity"
+)
+
+func TestSchedule_skipPages(t *testing.T) {
+	stored := []entity.Game{
+		{ExternalID: "ok-1", URL: "https://rolecon.ru/game/1", PageURL: "https://rolecon.ru/event/1"},
+		{ExternalID: "failed-1", URL: "https://rolecon.ru/game/2", PageURL: "https://rolecon.ru/event/2"},
+		{ExternalID: "failed-2", URL: "https://rolecon.ru/game/3", PageURL: "https://rolecon.ru/event/2"},
+		// Stored before pages were recorded
+		{ExternalID: "legacy-failed", URL: "https://rolecon.ru/event/3"},
+		{ExternalID: "legacy-ok", URL: "https://rolecon.ru/event/4"},
+	}
+
+	s := NewSchedule(nil)
+	checked := s.skipPages(stored, []string{"https://rolecon.ru/event/2", "https://rolecon.ru/event/3"})
+
+	var checkedIDs []string
+	for _, game := range checked {
+		checkedIDs = append(checkedIDs, game.ExternalID)
+	}
+	if want := []string{"ok-1", "legacy-ok"}; !slices.Equal(checkedIDs, want) {
+		t.Errorf("checked = %v, want %v", checkedIDs, want)
+	}
+	if want := []string{"failed-1", "failed-2", "legacy-failed"}; !slices.Equal(s.unchecked, want) {
+		t.Errorf("unchecked = %v, want %v", s.unchecked, want)
+	}
+
+	s = NewSchedule(nil)
+	if checked = s.skipPages(stored, nil); len(checked) != len(stored) || len(s.unchecked) != 0 {
+		t.Errorf("without skipped pages checked %d games and skipped %v, want all checked", len(checked), s.unchecked)
+	}
+}
+
+func TestSchedule_absentGames_PageCache(t *testing.T) {
+	stored := []entity.Game{
+		{ExternalID: "unchanged-1", PageURL: "https://rolecon.ru/event/1"},
+		{ExternalID: "unchanged-2", PageURL: "https://rolecon.ru/event/1"},
+		{ExternalID: "unchanged-3", PageURL: "https://rolecon.ru/event/2"},
+		{ExternalID: "unchanged-4", PageURL: "https://rolecon.ru/event/2"},
+		// Event removed from the calendar: its page is neither parsed nor unchanged
+		{ExternalID: "removed", PageURL: "https://rolecon.ru/event/3"},
+	}
+	guard := entity.AbsenceGuard{MaxAbsent: 20, MaxAbsentPercent: 30}
+
+	// Nothing else changed, so no page is parsed in the run
+	s := NewSchedule(nil)
+	absent, parsed := s.absentGames(stored, []string{"https://rolecon.ru/event/1", "https://rolecon.ru/event/2"})
+	if len(absent) != 1 || absent[0].ExternalID != "removed" {
+		t.Errorf("absent = %v, want only the game of the removed event", absent)
+	}
+	if parsed != 4 {
+		t.Errorf("parsed = %d, want 4 games of unchanged pages", parsed)
+	}
+	if reason := guard.Check(parsed, len(stored), len(absent)); len(reason) > 0 {
+		t.Errorf("guard reason = %q, want the removed event cancelled", reason)
+	}
+
+	// One page changed and keeps its games
+	s = NewSchedule(nil)
+	s.Add(entity.Game{ExternalID: "unchanged-3"}, entity.Game{ExternalID: "unchanged-4"})
+	absent, parsed = s.absentGames(stored, []string{"https://rolecon.ru/event/1"})
+	if len(absent) != 1 || parsed != 4 {
+		t.Errorf("absent = %v, parsed = %d, want the removed game and 4 parsed", absent, parsed)
+	}
+}
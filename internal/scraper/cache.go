@@ -0,0 +1,164 @@
+package scraper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// volatilePattern matches parts of the page changing on every request, e.g. CSRF token, ignored by the body hash
+var volatilePattern = regexp.MustCompile(`(name="(?:csrf-token|_csrf)"\s+(?:content|value)=)"[^"]*"`)
+
+// CacheEntry is the state of the page at the last run which parsed it
+type CacheEntry struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	Hash         string `json:"hash"`
+	// EventHash is the hash of the events JSON metadata of the page, it is parsed together with the page
+	EventHash string    `json:"event_hash,omitempty"`
+	ParsedAt  time.Time `json:"parsed_at"`
+}
+
+// Cache keeps validators and body hashes of event pages on disk, one JSON file per page, to send conditional
+// requests and detect unchanged pages. Changes are kept in memory until Commit, so the run failed after
+// fetching does not hide the changes from the next run
+type Cache struct {
+	dir string
+	// MaxAge forces full request and parsing of the page after it was not parsed for so long
+	MaxAge  time.Duration
+	mu      sync.Mutex
+	pending map[string]CacheEntry
+	// events are hashes of the events JSON metadata of the run by page URL
+	events map[string]string
+}
+
+// NewCache returns cache in the directory, creating it if needed
+func NewCache(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Cache{dir: dir, MaxAge: 24 * time.Hour, pending: make(map[string]CacheEntry)}, nil
+}
+
+// lookup returns the entry of the page, nil if there is none, it is unreadable or expired. Nil cache has no entries
+func (c *Cache) lookup(url string) *CacheEntry {
+	if c == nil {
+		return nil
+	}
+	data, err := os.ReadFile(c.path(url))
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			slog.Warn("cannot read page cache entry", "url", url, "error", err)
+		}
+		return nil
+	}
+	var entry CacheEntry
+	if err = json.Unmarshal(data, &entry); err != nil || entry.URL != url {
+		slog.Warn("ignoring invalid page cache entry", "url", url, "error", err)
+		return nil
+	}
+	if c.MaxAge > 0 && time.Since(entry.ParsedAt) > c.MaxAge {
+		return nil
+	}
+	if entry.EventHash != c.eventHash(url) {
+		// Games take dates and classes from the events JSON, so the page is parsed again
+		return nil
+	}
+	return &entry
+}
+
+// useEvents remembers the events JSON metadata of the run: the page which event changed is not unchanged
+func (c *Cache) useEvents(events map[string]RoleconEvent) {
+	if c == nil {
+		return
+	}
+	hashes := make(map[string]string, len(events))
+	for url, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			continue
+		}
+		sum := sha256.Sum256(data)
+		hashes[url] = hex.EncodeToString(sum[:])
+	}
+	c.mu.Lock()
+	c.events = hashes
+	c.mu.Unlock()
+}
+
+// eventHash returns the hash of the events JSON metadata of the page, empty if there is none
+func (c *Cache) eventHash(url string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.events[url]
+}
+
+// update remembers the page fetched in full and returns true if its body is the same as in the entry
+func (c *Cache) update(url string, entry *CacheEntry, header http.Header, body []byte) bool {
+	if c == nil {
+		return false
+	}
+	updated := CacheEntry{
+		URL:          url,
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+		Hash:         hashBody(body),
+		EventHash:    c.eventHash(url),
+		ParsedAt:     time.Now(),
+	}
+	unchanged := entry != nil && entry.Hash == updated.Hash && entry.EventHash == updated.EventHash
+	if unchanged {
+		updated.ParsedAt = entry.ParsedAt
+	}
+	c.mu.Lock()
+	c.pending[url] = updated
+	c.mu.Unlock()
+	return unchanged
+}
+
+// Commit writes entries of pages fetched since the last commit, call it after the fetched games are saved
+func (c *Cache) Commit() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for url, entry := range c.pending {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		// Write and rename, so the interrupted commit leaves either the old entry or the new one
+		tmp := c.path(url) + ".tmp"
+		if err = os.WriteFile(tmp, data, 0o644); err != nil {
+			return err
+		}
+		if err = os.Rename(tmp, c.path(url)); err != nil {
+			return err
+		}
+		delete(c.pending, url)
+	}
+	return nil
+}
+
+// path returns file of the page entry
+func (c *Cache) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// hashBody returns hash of the page body without volatile parts
+func hashBody(body []byte) string {
+	sum := sha256.Sum256(volatilePattern.ReplaceAll(body, []byte(`$1""`)))
+	return hex.EncodeToString(sum[:])
+}
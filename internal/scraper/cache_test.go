@@ -0,0 +1,195 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// loadCached loads the page through the cache and commits it
+func loadCached(t *testing.T, url string, cache *Cache) *Page {
+	t.Helper()
+	page := NewCachedPage(url, cache, nil)
+	if err := page.LoadHtml(context.Background()); err != nil {
+		t.Fatalf("LoadHtml() error = %v, want nil", err)
+	}
+	if err := cache.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v, want nil", err)
+	}
+	return page
+}
+
+func TestCache_ETag(t *testing.T) {
+	var conditional atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			conditional.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, "<html>event</html>")
+	}))
+	defer server.Close()
+	cache, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if page := loadCached(t, server.URL, cache); page.NotModified || page.Html != "<html>event</html>" {
+		t.Errorf("first load NotModified = %v, Html = %q, want the full page", page.NotModified, page.Html)
+	}
+	if page := loadCached(t, server.URL, cache); !page.NotModified {
+		t.Error("second load NotModified = false, want true")
+	}
+	if got := conditional.Load(); got != 1 {
+		t.Errorf("conditional requests = %d, want 1", got)
+	}
+}
+
+func TestCache_LastModified(t *testing.T) {
+	modified := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "event.html", modified, strings.NewReader("<html>event</html>"))
+	}))
+	defer server.Close()
+	cache, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loadCached(t, server.URL, cache)
+	if page := loadCached(t, server.URL, cache); !page.NotModified {
+		t.Error("second load NotModified = false, want true")
+	}
+}
+
+func TestCache_BodyHash(t *testing.T) {
+	var body atomic.Value
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// No validators, CSRF token changes on every request
+		fmt.Fprintf(w, `<html><meta name="csrf-token" content="token-%d">%s</html>`, requests.Add(1), body.Load())
+	}))
+	defer server.Close()
+	cache, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body.Store("2 seats")
+	loadCached(t, server.URL, cache)
+	if page := loadCached(t, server.URL, cache); !page.NotModified || len(page.Html) == 0 {
+		t.Errorf("same body NotModified = %v, Html = %q, want true with the page", page.NotModified, page.Html)
+	}
+	body.Store("1 seat")
+	if page := loadCached(t, server.URL, cache); page.NotModified {
+		t.Error("changed body NotModified = true, want false")
+	}
+	if page := loadCached(t, server.URL, cache); !page.NotModified {
+		t.Error("changed body is not remembered")
+	}
+}
+
+func TestCache_Uncommitted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.Header.Get("If-None-Match")) > 0 {
+			t.Error("conditional request sent before commit")
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, "<html>event</html>")
+	}))
+	defer server.Close()
+	cache, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The run failed before commit, the page is parsed again by the next run
+	if err = NewCachedPage(server.URL, cache, nil).LoadHtml(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	next, err := NewCache(cache.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page := loadCached(t, server.URL, next); page.NotModified {
+		t.Error("NotModified = true without committed entry, want false")
+	}
+}
+
+func TestCache_MaxAge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, "<html>event</html>")
+	}))
+	defer server.Close()
+	cache, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loadCached(t, server.URL, cache)
+	cache.MaxAge = time.Nanosecond
+	if page := loadCached(t, server.URL, cache); page.NotModified {
+		t.Error("expired entry NotModified = true, want full page")
+	}
+}
+
+func TestCache_Nil(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html>event</html>")
+	}))
+	defer server.Close()
+
+	page := NewCachedPage(server.URL, nil, nil)
+	if err := page.LoadHtml(context.Background()); err != nil || page.NotModified {
+		t.Errorf("LoadHtml() error = %v, NotModified = %v, want full page without cache", err, page.NotModified)
+	}
+	var cache *Cache
+	if err := cache.Commit(); err != nil {
+		t.Errorf("Commit() of nil cache error = %v, want nil", err)
+	}
+}
+
+func TestCache_EventChanged(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, "<html>event</html>")
+	}))
+	defer server.Close()
+	cache, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := RoleconEvent{ID: 1, URL: "/event/1", Start: "2025-03-01T12:00:00"}
+
+	cache.useEvents(map[string]RoleconEvent{server.URL: event})
+	loadCached(t, server.URL, cache)
+	if page := loadCached(t, server.URL, cache); !page.NotModified {
+		t.Error("load with the same event NotModified = false, want true")
+	}
+
+	// Event moved in the calendar while its page stayed the same
+	event.Start = "2025-03-02T12:00:00"
+	cache.useEvents(map[string]RoleconEvent{server.URL: event})
+	if page := loadCached(t, server.URL, cache); page.NotModified || page.Html != "<html>event</html>" {
+		t.Errorf("load with the changed event NotModified = %v, Html = %q, want the full page", page.NotModified, page.Html)
+	}
+	if page := loadCached(t, server.URL, cache); !page.NotModified {
+		t.Error("next load NotModified = false, want true")
+	}
+}
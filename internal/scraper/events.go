@@ -1,6 +1,7 @@
 package scraper
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -13,6 +14,7 @@ type Events struct {
 	Csrf   *Csrf
 	JSON   string
 	Events []RoleconEvent
+	client *Client
 }
 
 type RoleconEvent struct {
@@ -25,13 +27,13 @@ type RoleconEvent struct {
 	ClassName []string `json:"className"` // CSS class for event type (array in API)
 }
 
-func NewEvents(url string, csrf *Csrf) *Events {
-	return &Events{URL: url, Csrf: csrf}
+func NewEvents(url string, csrf *Csrf, client *Client) *Events {
+	return &Events{URL: url, Csrf: csrf, client: client}
 }
 
 // LoadEvents from the Rolecon website
-func (e *Events) LoadEvents() error {
-	req, err := http.NewRequest("GET", e.URL, nil)
+func (e *Events) LoadEvents(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", e.URL, nil)
 	if err != nil {
 		return err
 	}
@@ -46,7 +48,7 @@ func (e *Events) LoadEvents() error {
 	req.Header.Set("x-csrf-token", e.Csrf.Token)
 	req.Header.Set("x-requested-with", "XMLHttpRequest")
 
-	resp, err := httpClient().Do(req)
+	resp, err := e.client.Do(req)
 	if err != nil {
 		return err
 	}
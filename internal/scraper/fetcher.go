@@ -1,6 +1,7 @@
 package scraper
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"time"
@@ -16,6 +17,8 @@ const (
 type Fetcher struct {
 	rootURL   string
 	eventsURL string
+	client    *Client
+	cache     *Cache
 }
 
 // FetchResult contains all fetched pages and metadata.
@@ -24,22 +27,34 @@ type FetchResult struct {
 	Events   []RoleconEvent
 	EventMap map[string]RoleconEvent // Maps URL to event metadata
 	TotalURL int
+	Failed   []string // URLs of event pages failed to fetch
+	// Unchanged are URLs of event pages not modified since the last run, they are not in Pages
+	Unchanged []string
 }
 
-// NewFetcher creates a new fetcher with default URLs.
-func NewFetcher() *Fetcher {
+// NewFetcher creates a new fetcher with default URLs sending requests through the client.
+func NewFetcher(client *Client) *Fetcher {
 	return &Fetcher{
 		rootURL:   rootURL,
 		eventsURL: eventsURL,
+		client:    client,
 	}
 }
 
+// UseCache makes FetchAll tell the cache of event pages the events JSON metadata, so the page which event
+// changed is parsed again even if the page itself did not change; nil cache disables it
+func (f *Fetcher) UseCache(cache *Cache) {
+	f.cache = cache
+}
+
 // FetchAll performs the full fetch workflow: CSRF extraction, events JSON loading, and individual pages collection.
-func (f *Fetcher) FetchAll(fetchPages func([]string) ([]Page, error)) (*FetchResult, error) {
+// Cancelling ctx aborts requests in flight, fetchPages gets the same ctx. fetchPages returns pages fetched
+// and URLs failed to fetch; the run fails only if no page is fetched.
+func (f *Fetcher) FetchAll(ctx context.Context, fetchPages func(context.Context, []string) ([]Page, []string, error)) (*FetchResult, error) {
 	// Get the root page for CSRF
 	slog.Debug("requesting page", "url", f.rootURL)
-	page := NewPage(f.rootURL)
-	if err := page.LoadHtml(); err != nil {
+	page := NewPage(f.rootURL, f.client)
+	if err := page.LoadHtml(ctx); err != nil {
 		return nil, fmt.Errorf("failed to load root page: %w", err)
 	}
 	slog.Debug("initial page loaded", "size", len(page.Html), "cookies_count", len(page.Cookies))
@@ -58,8 +73,8 @@ func (f *Fetcher) FetchAll(fetchPages func([]string) ([]Page, error)) (*FetchRes
 	// Load events JSON
 	url := fmt.Sprintf(f.eventsURL, time.Now().Format("2006-01-02"), time.Now().Add(twoWeeks).Format("2006-01-02"))
 	slog.Debug("requesting events", "url", url)
-	events := NewEvents(url, csrf)
-	if err := events.LoadEvents(); err != nil {
+	events := NewEvents(url, csrf, f.client)
+	if err := events.LoadEvents(ctx); err != nil {
 		return nil, fmt.Errorf("failed to load events: %w", err)
 	}
 	slog.Debug("events page loaded", "size", len(events.JSON))
@@ -77,18 +92,37 @@ func (f *Fetcher) FetchAll(fetchPages func([]string) ([]Page, error)) (*FetchRes
 		eventMap[fullURL] = event
 	}
 
+	f.cache.useEvents(eventMap)
+
 	// Fetch individual pages using the provided function
 	slog.Debug("requesting events pages")
-	pages, err := fetchPages(urls)
+	pages, failed, err := fetchPages(ctx, urls)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch event pages: %w", err)
 	}
-	slog.Debug("collected events pages", "pages_count", len(pages))
+	if len(pages) == 0 && len(failed) > 0 {
+		return nil, fmt.Errorf("failed to fetch all %d event pages", len(failed))
+	}
+	if len(failed) > 0 {
+		slog.Warn("some events pages failed to fetch, keeping the rest", "failed_count", len(failed), "failed_urls", failed)
+	}
+	var changed []Page
+	var unchanged []string
+	for _, page := range pages {
+		if page.NotModified {
+			unchanged = append(unchanged, page.URL)
+		} else {
+			changed = append(changed, page)
+		}
+	}
+	slog.Debug("collected events pages", "pages_count", len(changed), "unchanged_count", len(unchanged))
 
 	return &FetchResult{
-		Pages:    pages,
-		Events:   events.Events,
-		EventMap: eventMap,
-		TotalURL: len(urls),
+		Pages:     changed,
+		Events:    events.Events,
+		EventMap:  eventMap,
+		TotalURL:  len(urls),
+		Failed:    failed,
+		Unchanged: unchanged,
 	}, nil
 }
@@ -1,6 +1,8 @@
 package scraper
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -38,20 +40,20 @@ func TestFetcher_FetchAll(t *testing.T) {
 	}
 
 	// Mock fetchPages function
-	fetchPages := func(urls []string) ([]Page, error) {
+	fetchPages := func(ctx context.Context, urls []string) ([]Page, []string, error) {
 		var pages []Page
 		for _, url := range urls {
-			p := NewPage(url)
-			if err := p.LoadHtml(); err != nil {
-				return nil, err
+			p := NewPage(url, nil)
+			if err := p.LoadHtml(ctx); err != nil {
+				return nil, nil, err
 			}
 			pages = append(pages, *p)
 		}
-		return pages, nil
+		return pages, nil, nil
 	}
 
 	// Execute fetch
-	result, err := fetcher.FetchAll(fetchPages)
+	result, err := fetcher.FetchAll(context.Background(), fetchPages)
 	if err != nil {
 		t.Fatalf("FetchAll() error = %v, want nil", err)
 	}
@@ -83,18 +85,98 @@ func TestFetcher_FetchAll_ErrorCases(t *testing.T) {
 		eventsURL: server.URL + "/event/json-calendar?start=%s&end=%s",
 	}
 
-	fetchPages := func(urls []string) ([]Page, error) {
-		return []Page{}, nil
+	fetchPages := func(ctx context.Context, urls []string) ([]Page, []string, error) {
+		return []Page{}, nil, nil
 	}
 
-	_, err := fetcher.FetchAll(fetchPages)
+	_, err := fetcher.FetchAll(context.Background(), fetchPages)
 	if err == nil {
 		t.Error("FetchAll() expected error, got nil")
 	}
 }
 
+func TestFetcher_FetchAll_PartialFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Set-Cookie", "_csrf=test-csrf-cookie")
+			fmt.Fprintf(w, `<html><head><meta name="csrf-token" content="test-csrf-token"></head></html>`)
+		case "/event/json-calendar":
+			fmt.Fprintf(w, `[{"id":1,"title":"First","url":"/event/1"},{"id":2,"title":"Second","url":"/event/2"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	fetcher := &Fetcher{
+		rootURL:   server.URL,
+		eventsURL: server.URL + "/event/json-calendar?start=%s&end=%s",
+	}
+
+	// The first page is fetched, the second one fails
+	result, err := fetcher.FetchAll(context.Background(), func(ctx context.Context, urls []string) ([]Page, []string, error) {
+		return []Page{{URL: urls[0], Html: "<html></html>"}}, urls[1:], nil
+	})
+	if err != nil {
+		t.Fatalf("FetchAll() error = %v, want nil", err)
+	}
+	if len(result.Pages) != 1 || result.Pages[0].URL != server.URL+"/event/1" {
+		t.Errorf("FetchAll() pages = %v, want the fetched page", result.Pages)
+	}
+	if len(result.Failed) != 1 || result.Failed[0] != server.URL+"/event/2" {
+		t.Errorf("FetchAll() failed = %v, want the second page", result.Failed)
+	}
+
+	// Unchanged pages are listed apart from pages to parse
+	result, err = fetcher.FetchAll(context.Background(), func(ctx context.Context, urls []string) ([]Page, []string, error) {
+		return []Page{{URL: urls[0], Html: "<html></html>"}, {URL: urls[1], NotModified: true}}, nil, nil
+	})
+	if err != nil {
+		t.Fatalf("FetchAll() error = %v, want nil", err)
+	}
+	if len(result.Pages) != 1 || len(result.Unchanged) != 1 || result.Unchanged[0] != server.URL+"/event/2" {
+		t.Errorf("FetchAll() pages = %v, unchanged = %v, want the second page unchanged", result.Pages, result.Unchanged)
+	}
+
+	// All pages failed, nothing to parse
+	_, err = fetcher.FetchAll(context.Background(), func(ctx context.Context, urls []string) ([]Page, []string, error) {
+		return nil, urls, nil
+	})
+	if err == nil {
+		t.Error("FetchAll() expected error when all pages failed, got nil")
+	}
+}
+
+func TestFetcher_FetchAll_CancelledContext(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	fetcher := &Fetcher{
+		rootURL:   server.URL,
+		eventsURL: server.URL + "/event/json-calendar?start=%s&end=%s",
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := fetcher.FetchAll(ctx, func(ctx context.Context, urls []string) ([]Page, []string, error) {
+		t.Error("fetchPages called after cancellation")
+		return nil, nil, nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("FetchAll() error = %v, want context.Canceled", err)
+	}
+	if requests != 0 {
+		t.Errorf("FetchAll() made %d requests after cancellation, want 0", requests)
+	}
+}
+
 func TestNewFetcher(t *testing.T) {
-	fetcher := NewFetcher()
+	fetcher := NewFetcher(nil)
 	if fetcher == nil {
 		t.Fatal("NewFetcher() returned nil")
 	}
@@ -125,11 +207,11 @@ func TestFetcher_FetchAll_EmptyEvents(t *testing.T) {
 		eventsURL: server.URL + "/event/json-calendar?start=%s&end=%s",
 	}
 
-	fetchPages := func(urls []string) ([]Page, error) {
-		return []Page{}, nil
+	fetchPages := func(ctx context.Context, urls []string) ([]Page, []string, error) {
+		return []Page{}, nil, nil
 	}
 
-	result, err := fetcher.FetchAll(fetchPages)
+	result, err := fetcher.FetchAll(context.Background(), fetchPages)
 	if err == nil {
 		t.Fatal("FetchAll() expected error for empty events, got nil")
 	}
@@ -1,6 +1,7 @@
 package scraper
 
 import (
+    "context"
     "fmt"
     "io"
     "log/slog"
@@ -11,19 +12,37 @@ type Page struct {
 	URL     string
 	Html    string
 	Cookies []*http.Cookie
+	// NotModified is set if the cache tells the page is the same as at the last parsing; Html may be empty then
+	NotModified bool
+	cache       *Cache
+	client      *Client
 }
 
-func NewPage(url string) *Page {
-	return &Page{URL: url}
+func NewPage(url string, client *Client) *Page {
+	return &Page{URL: url, client: client}
 }
 
-func (p *Page) LoadHtml() error {
-	req, err := http.NewRequest("GET", p.URL, nil)
+// NewCachedPage returns page requested conditionally with validators from the cache; nil cache disables it
+func NewCachedPage(url string, cache *Cache, client *Client) *Page {
+	return &Page{URL: url, cache: cache, client: client}
+}
+
+func (p *Page) LoadHtml(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.URL, nil)
 	if err != nil {
 		return err
 	}
+	entry := p.cache.lookup(p.URL)
+	if entry != nil {
+		if len(entry.ETag) > 0 {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if len(entry.LastModified) > 0 {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
 
-    resp, err := httpClient().Do(req)
+    resp, err := p.client.Do(req)
 	if err != nil {
 		return err
 	}
@@ -34,6 +53,10 @@ func (p *Page) LoadHtml() error {
 		}
 	}(resp.Body)
 
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		p.NotModified = true
+		return nil
+	}
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("received non-OK status code: %d", resp.StatusCode)
 	}
@@ -45,6 +68,7 @@ func (p *Page) LoadHtml() error {
 
 	p.Html = string(data)
 	p.Cookies = resp.Cookies()
+	p.NotModified = p.cache.update(p.URL, entry, resp.Header, data)
 
 	return nil
 }
@@ -0,0 +1,185 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrDisallowed is returned for requests robots.txt of the site does not allow
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+// robotsSizeLimit is the size of robots.txt parsed, the rest is ignored as RFC 9309 allows
+const robotsSizeLimit = 500 * 1024
+
+// robotsTimeout limits loading of robots.txt with all its attempts
+const robotsTimeout = time.Minute
+
+// Politeness makes the scraper a polite crawler: every request carries User-Agent with the contact address,
+// obeys robots.txt of its host and waits for its turn in the token bucket of the host
+type Politeness struct {
+	// UserAgent identifies the bot, its product token before "/" selects the robots.txt group
+	UserAgent string
+	// RPS is the number of requests per second to one host, zero means no limit; Crawl-delay of robots.txt
+	// lowers it. Burst is the number of requests sent without waiting after a pause
+	RPS   float64
+	Burst int
+
+	mu    sync.Mutex
+	hosts map[string]*hostPolicy
+}
+
+// hostPolicy is the state of one host: its robots.txt and token bucket
+type hostPolicy struct {
+	once   sync.Once
+	robots *robots
+	mu     sync.Mutex
+	bucket tokenBucket
+}
+
+// NewPoliteness returns politeness identifying the bot with the contact address, e-mail or URL
+func NewPoliteness(contact string, rps float64, burst int) *Politeness {
+	return &Politeness{
+		UserAgent: fmt.Sprintf("location-bot/1.0 (+%s)", contact),
+		RPS:       rps,
+		Burst:     max(1, burst),
+		hosts:     make(map[string]*hostPolicy),
+	}
+}
+
+// Admit sets User-Agent of the request, checks it against robots.txt of the host and waits for the turn
+// of the request. robots.txt is fetched by the retry policy. Nil politeness admits any request at once
+func (p *Politeness) Admit(req *http.Request, retry RetryPolicy) error {
+	if p == nil {
+		return nil
+	}
+	req.Header.Set("User-Agent", p.UserAgent)
+
+	host := p.host(req.URL)
+	host.once.Do(func() {
+		host.robots = p.loadRobots(req, retry)
+		if delay := host.robots.crawlDelay; delay > 0 {
+			host.mu.Lock()
+			if host.bucket.rate <= 0 || delay.Seconds()*host.bucket.rate > 1 {
+				host.bucket.rate, host.bucket.burst = 1/delay.Seconds(), 1
+				host.bucket.tokens = min(host.bucket.tokens, 1)
+			}
+			host.mu.Unlock()
+			slog.Info("robots.txt asks for crawl delay", "host", req.URL.Host, "delay", delay)
+		}
+	})
+	if !host.robots.allowed(req.URL.RequestURI()) {
+		return fmt.Errorf("%w: %s", ErrDisallowed, req.URL.String())
+	}
+
+	host.mu.Lock()
+	wait := host.bucket.reserve(time.Now())
+	host.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+	slog.Debug("waiting for the turn of the request", "url", req.URL.String(), "wait", wait)
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-req.Context().Done():
+		return req.Context().Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// host returns the state of the URL host, creating it on the first request
+func (p *Politeness) host(u *url.URL) *hostPolicy {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := u.Scheme + "://" + u.Host
+	host, ok := p.hosts[key]
+	if !ok {
+		host = &hostPolicy{bucket: tokenBucket{rate: p.RPS, burst: float64(p.Burst), tokens: float64(p.Burst)}}
+		p.hosts[key] = host
+	}
+	return host
+}
+
+// loadRobots fetches robots.txt of the request host by the retry policy, so a transient failure does not
+// stop the run. Missing robots.txt (4xx) allows everything, unreachable one (5xx, network error after
+// all attempts) disallows everything for this run as RFC 9309 requires. robots.txt is loaded once for all
+// requests to the host, so cancellation of the request which triggered loading does not abort it
+func (p *Politeness) loadRobots(req *http.Request, retry RetryPolicy) *robots {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(req.Context()), robotsTimeout)
+	defer cancel()
+	robotsURL := req.URL.Scheme + "://" + req.URL.Host + "/robots.txt"
+	robotsReq, err := http.NewRequestWithContext(ctx, "GET", robotsURL, nil)
+	if err != nil {
+		slog.Error("cannot request robots.txt", "url", robotsURL, "error", err)
+		return disallowAll
+	}
+	robotsReq.Header.Set("User-Agent", p.UserAgent)
+
+	resp, err := retry.Do(robotsReq, nil)
+	if err != nil {
+		slog.Error("robots.txt is unreachable, crawling of the host is disallowed", "url", robotsURL, "error", err)
+		return disallowAll
+	}
+	defer func(Body io.ReadCloser) {
+		err = Body.Close()
+		if err != nil {
+			slog.Error("failed to close response body", "url", robotsURL, "err", err)
+		}
+	}(resp.Body)
+
+	switch {
+	case resp.StatusCode >= 500:
+		slog.Error("robots.txt is unreachable, crawling of the host is disallowed", "url", robotsURL, "status", resp.StatusCode)
+		return disallowAll
+	case resp.StatusCode >= 400:
+		slog.Debug("robots.txt not found, crawling of the host is allowed", "url", robotsURL, "status", resp.StatusCode)
+		return allowAll
+	case resp.StatusCode != http.StatusOK:
+		slog.Warn("unexpected robots.txt response, crawling of the host is allowed", "url", robotsURL, "status", resp.StatusCode)
+		return allowAll
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, robotsSizeLimit))
+	if err != nil {
+		slog.Error("cannot read robots.txt, crawling of the host is disallowed", "url", robotsURL, "error", err)
+		return disallowAll
+	}
+	agent, _, _ := strings.Cut(p.UserAgent, "/")
+	rules := parseRobots(string(data), agent)
+	slog.Debug("robots.txt loaded", "url", robotsURL, "rules_count", len(rules.rules), "crawl_delay", rules.crawlDelay)
+	return rules
+}
+
+// tokenBucket refills rate tokens per second up to burst; zero rate means no limit
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// reserve takes a token and returns how long to wait until it is available. Tokens taken in advance make
+// the balance negative, so concurrent requests line up one after another
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	if b.rate <= 0 {
+		return 0
+	}
+	if !b.last.IsZero() {
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
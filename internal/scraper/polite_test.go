@@ -0,0 +1,294 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// politeClient returns client with the politeness retrying with short delays for the test
+func politeClient(p *Politeness) *Client {
+	return NewClient(fastRetries(3).Retry, p)
+}
+
+// politeServer serves robots.txt with the status and content, other paths respond with a page
+// and count requests by path
+func politeServer(t *testing.T, status int, content string) (*httptest.Server, *sync.Map) {
+	t.Helper()
+	var requests sync.Map
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		counter, _ := requests.LoadOrStore(r.URL.Path, new(atomic.Int32))
+		counter.(*atomic.Int32).Add(1)
+		if !strings.HasPrefix(r.Header.Get("User-Agent"), "location-bot/") {
+			t.Errorf("request %s User-Agent = %q, want location-bot", r.URL.Path, r.Header.Get("User-Agent"))
+		}
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(status)
+			fmt.Fprint(w, content)
+			return
+		}
+		fmt.Fprint(w, "<html>event</html>")
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+// requestsTo returns number of requests to the path
+func requestsTo(requests *sync.Map, path string) int32 {
+	counter, ok := requests.Load(path)
+	if !ok {
+		return 0
+	}
+	return counter.(*atomic.Int32).Load()
+}
+
+func TestPoliteness_Robots(t *testing.T) {
+	server, requests := politeServer(t, http.StatusOK, "User-agent: *\nDisallow: /private\n")
+	client := politeClient(NewPoliteness("admin@example.com", 0, 1))
+
+	for _, path := range []string{"/event/1", "/event/2"} {
+		if err := NewPage(server.URL+path, client).LoadHtml(context.Background()); err != nil {
+			t.Errorf("LoadHtml(%s) error = %v, want nil", path, err)
+		}
+	}
+	err := NewPage(server.URL+"/private/1", client).LoadHtml(context.Background())
+	if !errors.Is(err, ErrDisallowed) {
+		t.Errorf("LoadHtml(/private/1) error = %v, want ErrDisallowed", err)
+	}
+
+	if got := requestsTo(requests, "/private/1"); got != 0 {
+		t.Errorf("requests to disallowed page = %d, want 0", got)
+	}
+	if got := requestsTo(requests, "/robots.txt"); got != 1 {
+		t.Errorf("robots.txt requests = %d, want 1", got)
+	}
+}
+
+func TestPoliteness_UserAgent(t *testing.T) {
+	var userAgent atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/robots.txt" {
+			userAgent.Store(r.Header.Get("User-Agent"))
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+	client := politeClient(NewPoliteness("admin@example.com", 0, 1))
+
+	events := NewEvents(server.URL+"/events", &Csrf{Token: "token", Cookie: "cookie"}, client)
+	_ = events.LoadEvents(context.Background())
+	if got, _ := userAgent.Load().(string); got != "location-bot/1.0 (+admin@example.com)" {
+		t.Errorf("User-Agent = %q, want location-bot with the contact", got)
+	}
+}
+
+func TestPoliteness_RobotsUnreachable(t *testing.T) {
+	server, requests := politeServer(t, http.StatusServiceUnavailable, "")
+	client := politeClient(NewPoliteness("admin@example.com", 0, 1))
+
+	err := NewPage(server.URL+"/event/1", client).LoadHtml(context.Background())
+	if !errors.Is(err, ErrDisallowed) {
+		t.Errorf("LoadHtml() error = %v, want ErrDisallowed", err)
+	}
+	if got := requestsTo(requests, "/event/1"); got != 0 {
+		t.Errorf("requests to the page = %d, want 0 while robots.txt is unreachable", got)
+	}
+}
+
+func TestPoliteness_RobotsTransientFailure(t *testing.T) {
+	var robotsRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" && robotsRequests.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, "<html>event</html>")
+	}))
+	defer server.Close()
+	client := politeClient(NewPoliteness("admin@example.com", 0, 1))
+
+	if err := NewPage(server.URL+"/event/1", client).LoadHtml(context.Background()); err != nil {
+		t.Errorf("LoadHtml() error = %v, want nil after robots.txt is retried", err)
+	}
+	if got := robotsRequests.Load(); got != 2 {
+		t.Errorf("robots.txt requests = %d, want 2", got)
+	}
+}
+
+func TestPoliteness_RobotsMissing(t *testing.T) {
+	server, _ := politeServer(t, http.StatusNotFound, "")
+	client := politeClient(NewPoliteness("admin@example.com", 0, 1))
+
+	if err := NewPage(server.URL+"/event/1", client).LoadHtml(context.Background()); err != nil {
+		t.Errorf("LoadHtml() error = %v, want nil without robots.txt", err)
+	}
+}
+
+func TestPoliteness_RateLimit(t *testing.T) {
+	server, _ := politeServer(t, http.StatusNotFound, "")
+	client := politeClient(NewPoliteness("admin@example.com", 20, 1))
+
+	// Five concurrent workers share the bucket of the host: 1 request at once and 4 more at 20 per second
+	start := time.Now()
+	var wg sync.WaitGroup
+	for k := 0; k < 5; k++ {
+		wg.Add(1)
+		go func(k int) {
+			defer wg.Done()
+			if err := NewPage(fmt.Sprintf("%s/event/%d", server.URL, k), client).LoadHtml(context.Background()); err != nil {
+				t.Errorf("LoadHtml() error = %v, want nil", err)
+			}
+		}(k)
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 190*time.Millisecond {
+		t.Errorf("elapsed = %v, want at least 200ms for 5 requests at 20 per second", elapsed)
+	}
+}
+
+func TestPoliteness_CrawlDelay(t *testing.T) {
+	server, _ := politeServer(t, http.StatusOK, "User-agent: *\nCrawl-delay: 0.1\n")
+	client := politeClient(NewPoliteness("admin@example.com", 0, 5))
+
+	start := time.Now()
+	for k := 0; k < 3; k++ {
+		if err := NewPage(fmt.Sprintf("%s/event/%d", server.URL, k), client).LoadHtml(context.Background()); err != nil {
+			t.Fatalf("LoadHtml() error = %v, want nil", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 190*time.Millisecond {
+		t.Errorf("elapsed = %v, want at least 200ms for 3 requests with crawl delay 0.1s", elapsed)
+	}
+}
+
+func TestPoliteness_Cancelled(t *testing.T) {
+	server, _ := politeServer(t, http.StatusNotFound, "")
+	client := politeClient(NewPoliteness("admin@example.com", 0.1, 1))
+
+	if err := NewPage(server.URL+"/event/1", client).LoadHtml(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// The next turn is in 10 seconds
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := NewPage(server.URL+"/event/2", client).LoadHtml(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("LoadHtml() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestPoliteness_RobotsOutlivesTriggeringRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			time.Sleep(100 * time.Millisecond)
+			fmt.Fprint(w, "User-agent: *\nDisallow: /private\n")
+			return
+		}
+		fmt.Fprint(w, "<html>event</html>")
+	}))
+	defer server.Close()
+	client := politeClient(NewPoliteness("admin@example.com", 0, 1))
+
+	// The first request gives up while robots.txt is loading
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := NewPage(server.URL+"/event/1", client).LoadHtml(ctx); err == nil {
+		t.Error("LoadHtml() of the cancelled request error = nil")
+	}
+	// robots.txt was loaded anyway and other requests are not disallowed for the rest of the run
+	if err := NewPage(server.URL+"/event/2", client).LoadHtml(context.Background()); err != nil {
+		t.Errorf("LoadHtml() error = %v, want nil", err)
+	}
+	if err := NewPage(server.URL+"/private", client).LoadHtml(context.Background()); !errors.Is(err, ErrDisallowed) {
+		t.Errorf("LoadHtml(/private) error = %v, want ErrDisallowed", err)
+	}
+}
+
+func TestTokenBucket_reserve(t *testing.T) {
+	bucket := tokenBucket{rate: 2, burst: 2, tokens: 2}
+	now := time.Now()
+	waits := []time.Duration{
+		bucket.reserve(now),
+		bucket.reserve(now),
+		bucket.reserve(now),
+		bucket.reserve(now),
+	}
+	want := []time.Duration{0, 0, 500 * time.Millisecond, time.Second}
+	for k := range want {
+		if waits[k] != want[k] {
+			t.Errorf("reserve() #%d = %v, want %v", k+1, waits[k], want[k])
+		}
+	}
+	// After a long pause the bucket is full again but not above burst
+	if wait := bucket.reserve(now.Add(time.Minute)); wait != 0 {
+		t.Errorf("reserve() after pause = %v, want 0", wait)
+	}
+	if bucket.tokens != 1 {
+		t.Errorf("tokens = %v, want 1", bucket.tokens)
+	}
+}
+
+func TestParseRobots(t *testing.T) {
+	content := `# comment
+User-agent: *
+Disallow: /admin
+Crawl-delay: 5
+
+User-agent: other-bot
+User-agent: location-bot
+Disallow: /event/*/edit
+Disallow: /*.json$
+Allow: /private/open
+Disallow: /private
+Crawl-delay: 0.5
+
+User-agent: location-bot
+Disallow: /search
+`
+	rules := parseRobots(content, "location-bot")
+	if rules.crawlDelay != 500*time.Millisecond {
+		t.Errorf("crawlDelay = %v, want 500ms of the agent group", rules.crawlDelay)
+	}
+	tests := []struct {
+		path    string
+		allowed bool
+	}{
+		{"/event/1", true},
+		{"/admin", true}, // rules of "*" do not apply when the agent has its group
+		{"/event/1/edit", false},
+		{"/data.json", false},
+		{"/data.json?page=1", true},
+		{"/private/open/1", true},
+		{"/private/closed", false},
+		{"/search?q=dnd", false},
+		{"/robots.txt", true},
+	}
+	for _, tt := range tests {
+		if got := rules.allowed(tt.path); got != tt.allowed {
+			t.Errorf("allowed(%s) = %v, want %v", tt.path, got, tt.allowed)
+		}
+	}
+
+	common := parseRobots(content, "another-crawler")
+	if common.allowed("/admin/users") || !common.allowed("/search") || common.crawlDelay != 5*time.Second {
+		t.Errorf("another crawler got rules %+v, want the \"*\" group", common)
+	}
+	// Product token is compared exactly, ignoring case
+	if parseRobots("User-agent: Location-Bot\nDisallow: /\n", "location-bot").allowed("/event/1") {
+		t.Error("group of the agent in other case is ignored")
+	}
+	for _, name := range []string{"bot", "location", "location-bot-extra"} {
+		if !parseRobots("User-agent: "+name+"\nDisallow: /\n", "location-bot").allowed("/event/1") {
+			t.Errorf("group of %q applies to location-bot", name)
+		}
+	}
+	if !parseRobots("", "location-bot").allowed("/anything") {
+		t.Error("empty robots.txt disallows, want everything allowed")
+	}
+}
@@ -0,0 +1,168 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy repeats requests failed with transient errors: timeouts, dropped connections, 5xx and 429 responses.
+// Other responses, including 4xx, are returned to the caller as is
+type RetryPolicy struct {
+	// Attempts is the maximum number of requests, 1 disables retries
+	Attempts int
+	// BaseDelay is the delay before the second attempt, it is doubled for each next one up to MaxDelay
+	// and randomized by jitter to avoid retrying in lockstep with other workers
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// MaxElapsed limits total time of all attempts and delays; the attempt is not made if its delay exceeds it
+	MaxElapsed time.Duration
+}
+
+// DefaultRetryPolicy returns policy with 3 attempts, starting from 1 second delay, within 1 minute
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		Attempts:   3,
+		BaseDelay:  time.Second,
+		MaxDelay:   10 * time.Second,
+		MaxElapsed: time.Minute,
+	}
+}
+
+// Client sends requests of Page, Events and Fetcher with the retry policy and politeness of the run
+type Client struct {
+	Retry RetryPolicy
+	// Politeness admits every attempt, nil disables it
+	Politeness *Politeness
+}
+
+// NewClient returns client repeating requests by the retry policy; nil politeness disables it
+func NewClient(retry RetryPolicy, politeness *Politeness) *Client {
+	return &Client{Retry: retry, Politeness: politeness}
+}
+
+// Do sends the request by the retry policy of the client, every attempt is admitted by its politeness first.
+// Nil client uses [DefaultRetryPolicy] without politeness
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if c == nil {
+		return DefaultRetryPolicy().Do(req, nil)
+	}
+	return c.Retry.Do(req, func(attemptReq *http.Request) error {
+		return c.Politeness.Admit(attemptReq, c.Retry)
+	})
+}
+
+// Do sends the request until it succeeds, fails permanently or the policy is exhausted; every attempt is
+// passed to admit first, if any. The response of the last attempt is returned even if its status
+// is retryable, so the caller reports the status it got. The request must not have a body
+func (p RetryPolicy) Do(req *http.Request, admit func(*http.Request) error) (*http.Response, error) {
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		slog.Debug("sending request", "url", req.URL.String(), "attempt", attempt)
+		attemptReq := req.Clone(req.Context())
+		if admit != nil {
+			if err := admit(attemptReq); err != nil {
+				return nil, err
+			}
+		}
+		resp, err := httpClient().Do(attemptReq)
+		retryable, retryAfter := p.classify(resp, err)
+		if req.Context().Err() != nil {
+			// Deadline of the whole run is not a transient timeout
+			return resp, err
+		}
+		if !retryable || attempt >= p.Attempts {
+			if retryable {
+				slog.Warn("request failed, no attempts left", "url", req.URL.String(), "attempt", attempt, "status", status(resp), "error", err)
+			}
+			return resp, err
+		}
+
+		delay := p.backoff(attempt)
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+		if p.MaxElapsed > 0 && time.Since(start)+delay > p.MaxElapsed {
+			slog.Warn("request failed, retry time exhausted", "url", req.URL.String(), "attempt", attempt, "status", status(resp), "error", err, "elapsed", time.Since(start))
+			return resp, err
+		}
+		slog.Warn("request failed, retrying", "url", req.URL.String(), "attempt", attempt, "status", status(resp), "error", err, "delay", delay)
+		if resp != nil {
+			// Drain the body so the connection is reused
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// classify returns true if the failure is transient and the delay requested by the server with Retry-After
+func (p RetryPolicy) classify(resp *http.Response, err error) (bool, time.Duration) {
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return false, 0
+		}
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return true, 0
+		}
+		return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+			errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF), 0
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return true, parseRetryAfter(resp.Header.Get("Retry-After"))
+	}
+	return false, 0
+}
+
+// backoff returns exponential delay before the attempt following the given one with equal jitter:
+// a random value from the half to the full delay
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// parseRetryAfter returns the delay of Retry-After header in seconds or HTTP date, zero if absent or invalid
+func parseRetryAfter(value string) time.Duration {
+	if len(value) == 0 {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(0, time.Until(date))
+	}
+	return 0
+}
+
+// status returns the response status code for logs, 0 if there is no response
+func status(resp *http.Response) int {
+	if resp == nil {
+		return 0
+	}
+	return resp.StatusCode
+}
@@ -0,0 +1,253 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fastRetries returns client retrying with short delays for the test
+func fastRetries(attempts int) *Client {
+	return NewClient(RetryPolicy{Attempts: attempts, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond, MaxElapsed: 5 * time.Second}, nil)
+}
+
+// flakyServer fails the first failures requests with the status and then responds with the body
+func flakyServer(t *testing.T, failures int32, status int, body string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestPage_LoadHtml_RetriesTransientStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{"bad gateway", http.StatusBadGateway},
+		{"service unavailable", http.StatusServiceUnavailable},
+		{"too many requests", http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fastRetries(3)
+			server, requests := flakyServer(t, 2, tt.status, "<html>ok</html>")
+
+			page := NewPage(server.URL, client)
+			if err := page.LoadHtml(context.Background()); err != nil {
+				t.Fatalf("LoadHtml() error = %v, want nil", err)
+			}
+			if page.Html != "<html>ok</html>" {
+				t.Errorf("Html = %q, want the page of the successful attempt", page.Html)
+			}
+			if got := requests.Load(); got != 3 {
+				t.Errorf("requests = %d, want 3", got)
+			}
+		})
+	}
+}
+
+func TestPage_LoadHtml_PermanentStatus(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusForbidden, http.StatusBadRequest} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			client := fastRetries(3)
+			server, requests := flakyServer(t, 10, status, "")
+
+			err := NewPage(server.URL, client).LoadHtml(context.Background())
+			if err == nil || !strings.Contains(err.Error(), fmt.Sprint(status)) {
+				t.Errorf("LoadHtml() error = %v, want error with status %d", err, status)
+			}
+			if got := requests.Load(); got != 1 {
+				t.Errorf("requests = %d, want 1 (permanent failure is not retried)", got)
+			}
+		})
+	}
+}
+
+func TestPage_LoadHtml_AttemptsExhausted(t *testing.T) {
+	client := fastRetries(4)
+	server, requests := flakyServer(t, 10, http.StatusInternalServerError, "")
+
+	err := NewPage(server.URL, client).LoadHtml(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("LoadHtml() error = %v, want error with status of the last attempt", err)
+	}
+	if got := requests.Load(); got != 4 {
+		t.Errorf("requests = %d, want 4", got)
+	}
+}
+
+func TestPage_LoadHtml_DroppedConnection(t *testing.T) {
+	client := fastRetries(3)
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = conn.Close()
+			return
+		}
+		fmt.Fprint(w, "<html>ok</html>")
+	}))
+	defer server.Close()
+
+	page := NewPage(server.URL, client)
+	if err := page.LoadHtml(context.Background()); err != nil {
+		t.Fatalf("LoadHtml() error = %v, want nil", err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("requests = %d, want 2", got)
+	}
+}
+
+func TestPage_LoadHtml_RetryAfter(t *testing.T) {
+	client := fastRetries(2)
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, "<html>ok</html>")
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	if err := NewPage(server.URL, client).LoadHtml(context.Background()); err != nil {
+		t.Fatalf("LoadHtml() error = %v, want nil", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("elapsed = %v, want at least Retry-After delay of 1s", elapsed)
+	}
+}
+
+func TestPage_LoadHtml_MaxElapsed(t *testing.T) {
+	client := NewClient(RetryPolicy{Attempts: 5, BaseDelay: time.Second, MaxDelay: time.Second, MaxElapsed: 100 * time.Millisecond}, nil)
+	server, requests := flakyServer(t, 10, http.StatusBadGateway, "")
+
+	start := time.Now()
+	if err := NewPage(server.URL, client).LoadHtml(context.Background()); err == nil {
+		t.Fatal("LoadHtml() error = nil, want error")
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("requests = %d, want 1 (delay exceeds total time)", got)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("elapsed = %v, want no waiting beyond total time", elapsed)
+	}
+}
+
+func TestEvents_LoadEvents_RetriesTransientStatus(t *testing.T) {
+	client := fastRetries(3)
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-csrf-token") != "token" {
+			t.Errorf("retried request lost headers: x-csrf-token = %q", r.Header.Get("x-csrf-token"))
+		}
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, `[{"id":1,"title":"Test Event","url":"/event/1"}]`)
+	}))
+	defer server.Close()
+
+	events := NewEvents(server.URL, &Csrf{Token: "token", Cookie: "cookie"}, client)
+	if err := events.LoadEvents(context.Background()); err != nil {
+		t.Fatalf("LoadEvents() error = %v, want nil", err)
+	}
+	if err := events.UnmarshalEvents(); err != nil {
+		t.Fatalf("UnmarshalEvents() error = %v, want nil", err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("requests = %d, want 2", got)
+	}
+}
+
+// timeoutError is a network error reporting timeout
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestRetryPolicy_Classify(t *testing.T) {
+	policy := DefaultRetryPolicy()
+	tests := []struct {
+		name       string
+		resp       *http.Response
+		err        error
+		retryable  bool
+		retryAfter time.Duration
+	}{
+		{"timeout", nil, &url.Error{Op: "Get", URL: "http://example.com", Err: timeoutError{}}, true, 0},
+		{"other error", nil, errors.New("unsupported protocol scheme"), false, 0},
+		{"ok", &http.Response{StatusCode: http.StatusOK}, nil, false, 0},
+		{"not found", &http.Response{StatusCode: http.StatusNotFound}, nil, false, 0},
+		{"gateway timeout", &http.Response{StatusCode: http.StatusGatewayTimeout, Header: http.Header{}}, nil, true, 0},
+		{"too many requests", &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"7"}}}, nil, true, 7 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retryable, retryAfter := policy.classify(tt.resp, tt.err)
+			if retryable != tt.retryable || retryAfter != tt.retryAfter {
+				t.Errorf("classify() = %v, %v, want %v, %v", retryable, retryAfter, tt.retryable, tt.retryAfter)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	tests := []struct {
+		attempt int
+		full    time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{10, time.Second},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			if delay := policy.backoff(tt.attempt); delay < tt.full/2 || delay > tt.full {
+				t.Errorf("backoff(%d) = %v, want from %v to %v", tt.attempt, delay, tt.full/2, tt.full)
+			}
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if got := parseRetryAfter("3"); got != 3*time.Second {
+		t.Errorf("parseRetryAfter(3) = %v, want 3s", got)
+	}
+	if got := parseRetryAfter(""); got != 0 {
+		t.Errorf("parseRetryAfter(\"\") = %v, want 0", got)
+	}
+	if got := parseRetryAfter("soon"); got != 0 {
+		t.Errorf("parseRetryAfter(soon) = %v, want 0", got)
+	}
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(date); got <= 30*time.Second || got > time.Minute {
+		t.Errorf("parseRetryAfter(%s) = %v, want about 1m", date, got)
+	}
+}
@@ -0,0 +1,118 @@
+package scraper
+
+import (
+	"bufio"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robotsRule allows or disallows paths matching the pattern
+type robotsRule struct {
+	pattern string
+	allow   bool
+	match   *regexp.Regexp
+}
+
+// robots are rules of robots.txt group applying to the crawler, see RFC 9309
+type robots struct {
+	rules []robotsRule
+	// crawlDelay is the pause between requests asked by the site, zero if not set
+	crawlDelay time.Duration
+}
+
+// allowAll is the policy of the site without robots.txt
+var allowAll = &robots{}
+
+// disallowAll is the policy of the site which robots.txt is unreachable
+var disallowAll = &robots{rules: []robotsRule{newRobotsRule("/", false)}}
+
+// parseRobots returns rules of the group for the agent, the product token of User-Agent, or of the "*" group
+// if there is no group for the agent. The group is for the agent if it names the product token exactly,
+// ignoring case as RFC 9309 requires. Groups naming the same agent are merged
+func parseRobots(content, agent string) *robots {
+	agent = strings.ToLower(agent)
+	specific, common := &robots{}, &robots{}
+	var found bool
+	// Current group applies to the agent and to all agents
+	var forAgent, forAll, inAgents bool
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+
+		if key == "user-agent" {
+			// Consecutive user-agent lines start one group
+			if !inAgents {
+				forAgent, forAll = false, false
+			}
+			inAgents = true
+			name := strings.ToLower(value)
+			if name == "*" {
+				forAll = true
+			} else if name == agent {
+				forAgent, found = true, true
+			}
+			continue
+		}
+		inAgents = false
+
+		var groups []*robots
+		if forAgent {
+			groups = append(groups, specific)
+		}
+		if forAll {
+			groups = append(groups, common)
+		}
+		for _, group := range groups {
+			switch key {
+			case "allow", "disallow":
+				if len(value) > 0 {
+					group.rules = append(group.rules, newRobotsRule(value, key == "allow"))
+				}
+			case "crawl-delay":
+				if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+					group.crawlDelay = time.Duration(seconds * float64(time.Second))
+				}
+			}
+		}
+	}
+
+	if found {
+		return specific
+	}
+	return common
+}
+
+// newRobotsRule compiles the path pattern: "*" matches any characters and "$" at the end anchors it
+func newRobotsRule(pattern string, allow bool) robotsRule {
+	anchored := strings.HasSuffix(pattern, "$")
+	expression := "^" + strings.ReplaceAll(regexp.QuoteMeta(strings.TrimSuffix(pattern, "$")), `\*`, ".*")
+	if anchored {
+		expression += "$"
+	}
+	return robotsRule{pattern: pattern, allow: allow, match: regexp.MustCompile(expression)}
+}
+
+// allowed returns true if the path with query may be requested: the longest matching rule wins, allow wins the tie
+func (r *robots) allowed(path string) bool {
+	if path == "/robots.txt" {
+		return true
+	}
+	allowed, longest := true, -1
+	for _, rule := range r.rules {
+		if !rule.match.MatchString(path) {
+			continue
+		}
+		if len(rule.pattern) > longest || (len(rule.pattern) == longest && rule.allow) {
+			allowed, longest = rule.allow, len(rule.pattern)
+		}
+	}
+	return allowed
+}
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"github.com/kettari/location-bot/internal/entity"
+)
+
+// FindAnnouncements returns messages about the game posted to notification chats
+func (m *Manager) FindAnnouncements(gameID uint) ([]entity.Announcement, error) {
+	if err := m.Connect(); err != nil {
+		return nil, err
+	}
+	var announcements []entity.Announcement
+	result := m.db.Where(&entity.Announcement{GameID: gameID}).Order("id ASC").Find(&announcements)
+	return announcements, result.Error
+}
+
+// CreateAnnouncements stores posted messages about the game
+func (m *Manager) CreateAnnouncements(announcements []entity.Announcement) error {
+	if len(announcements) == 0 {
+		return nil
+	}
+	if err := m.Connect(); err != nil {
+		return err
+	}
+	return m.db.Create(&announcements).Error
+}
+
+// DeleteAnnouncement forgets the message which cannot be edited anymore, e.g. deleted from the chat
+func (m *Manager) DeleteAnnouncement(announcement *entity.Announcement) error {
+	if err := m.Connect(); err != nil {
+		return err
+	}
+	return m.db.Delete(announcement).Error
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"github.com/kettari/location-bot/internal/entity"
+	"gorm.io/gorm/clause"
+)
+
+// BoardMessages returns posted schedule board messages of all chats
+func (m *Manager) BoardMessages() ([]entity.BoardMessage, error) {
+	if err := m.Connect(); err != nil {
+		return nil, err
+	}
+	var messages []entity.BoardMessage
+	result := m.db.Order("chat_id, thread_id, position").Find(&messages)
+	return messages, result.Error
+}
+
+// SaveBoardMessages stores board messages, replacing message IDs of reposted ones
+func (m *Manager) SaveBoardMessages(messages []entity.BoardMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	if err := m.Connect(); err != nil {
+		return err
+	}
+	return m.db.
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "chat_id"}, {Name: "thread_id"}, {Name: "position"}},
+			DoUpdates: clause.AssignmentColumns([]string{"message_id", "updated_at"}),
+		}).
+		Create(&messages).Error
+}
+
+// DeleteBoardMessages forgets board messages removed from the chats
+func (m *Manager) DeleteBoardMessages(messages []entity.BoardMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	if err := m.Connect(); err != nil {
+		return err
+	}
+	for _, message := range messages {
+		if err := m.db.Unscoped().
+			Where("chat_id = ? AND thread_id = ? AND position = ?", message.ChatID, message.ThreadID, message.Position).
+			Delete(&entity.BoardMessage{}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"errors"
+	"time"
+
+	"github.com/kettari/location-bot/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LastDigest returns the last sent digest of the period, nil if none was sent yet
+func (m *Manager) LastDigest(period entity.DigestPeriod) (*entity.Digest, error) {
+	if err := m.Connect(); err != nil {
+		return nil, err
+	}
+	digest := &entity.Digest{}
+	result := m.db.Where(&entity.Digest{Period: period}).First(digest)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return digest, result.Error
+}
+
+// SaveDigest creates or replaces the last digest of the period
+func (m *Manager) SaveDigest(digest *entity.Digest) error {
+	if err := m.Connect(); err != nil {
+		return err
+	}
+	return m.db.
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "period"}},
+			DoUpdates: clause.AssignmentColumns([]string{"until", "updated_at"}),
+		}).
+		Create(digest).Error
+}
+
+// GamesWithEvents returns future games which had events of the subject recorded in the outbox
+// after since and not after until
+func (m *Manager) GamesWithEvents(subjects []entity.SubjectType, since, until time.Time) ([]entity.Game, error) {
+	if err := m.Connect(); err != nil {
+		return nil, err
+	}
+	events := m.db.
+		Model(&entity.Notification{}).
+		Select("game_id").
+		Where("subject IN ?", subjects).
+		Where("created_at > ? AND created_at <= ?", since, until)
+	var games []entity.Game
+	result := m.db.
+		Where("id IN (?)", events).
+		Where("date > ?", until).
+		Order("date ASC").
+		Find(&games)
+	return games, result.Error
+}
@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"github.com/kettari/location-bot/internal/entity"
+)
+
+// CreateFetchAnomaly records the fetch run which cancellations were skipped
+func (m *Manager) CreateFetchAnomaly(anomaly *entity.FetchAnomaly) error {
+	if err := m.Connect(); err != nil {
+		return err
+	}
+	return m.db.Create(anomaly).Error
+}
+
+// SaveAbsentRuns stores the number of consecutive runs the game was absent from without touching
+// its update time, which is the version of the game state for notifications
+func (m *Manager) SaveAbsentRuns(game *entity.Game) error {
+	if err := m.Connect(); err != nil {
+		return err
+	}
+	return m.db.Model(game).UpdateColumn("absent_runs", game.AbsentRuns).Error
+}
@@ -1,6 +1,9 @@
 package storage
 
 import (
+	"context"
+	"sync"
+
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/schema"
@@ -9,6 +12,10 @@ import (
 type Manager struct {
 	connectionString string
 	db               *gorm.DB
+	// ctx cancels queries of the manager, see [Manager.WithContext]
+	ctx context.Context
+	// mu guards the lazy connection of the manager shared by handlers of the bot daemon
+	mu sync.Mutex
 }
 
 func NewManager(connectionString string) *Manager {
@@ -18,6 +25,8 @@ func NewManager(connectionString string) *Manager {
 func (m *Manager) Connect() error {
 	var err error
 
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if m.db != nil {
 		return nil
 	}
@@ -30,10 +39,54 @@ func (m *Manager) Connect() error {
 	if err != nil {
 		return err
 	}
+	if m.ctx != nil {
+		m.db = m.db.WithContext(m.ctx)
+	}
 
 	return nil
 }
 
+// WithContext returns the manager sharing the connection which queries are cancelled together with ctx;
+// nil manager stays nil
+func (m *Manager) WithContext(ctx context.Context) *Manager {
+	if m == nil {
+		return nil
+	}
+	bound := &Manager{connectionString: m.connectionString, ctx: ctx}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.db != nil {
+		bound.db = m.db.WithContext(ctx)
+	}
+	return bound
+}
+
+// Close closes the connection pool of the manager. Managers returned by [Manager.WithContext] share the pool,
+// so only the manager which opened it closes it, after all of them are done
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.db == nil {
+		return nil
+	}
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return err
+	}
+	m.db = nil
+	return sqlDB.Close()
+}
+
 func (m *Manager) DB() *gorm.DB {
 	return m.db
 }
+
+// Transaction runs fn with the manager bound to a database transaction; returned error rolls the transaction back
+func (m *Manager) Transaction(fn func(tx *Manager) error) error {
+	if err := m.Connect(); err != nil {
+		return err
+	}
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&Manager{connectionString: m.connectionString, db: tx})
+	})
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"slices"
+	"time"
+
+	"github.com/kettari/location-bot/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EnqueueNotifications writes notifications to the outbox; notifications with known idempotency keys are skipped
+func (m *Manager) EnqueueNotifications(notifications []entity.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	if err := m.Connect(); err != nil {
+		return err
+	}
+	return m.db.
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "idempotency_key"}}, DoNothing: true}).
+		Create(&notifications).Error
+}
+
+// ClaimNotifications locks due pending notifications for delivery. Each claim counts as an attempt
+// and hides the notification for the lease time, so a crashed worker does not block it forever
+// and concurrent workers do not send it twice. Due digest notifications of the chats in the batch are claimed
+// together with it beyond the limit, so the digest of the user is sent as one message
+func (m *Manager) ClaimNotifications(limit int, lease time.Duration) ([]entity.Notification, error) {
+	if err := m.Connect(); err != nil {
+		return nil, err
+	}
+	var notifications []entity.Notification
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where(&entity.Notification{Status: entity.NotificationStatusPending}).
+			Where("next_attempt_at <= ?", now).
+			Order("id ASC").
+			Limit(limit).
+			Find(&notifications).Error; err != nil {
+			return err
+		}
+		var digestChats []int64
+		var claimed []uint
+		for _, notification := range notifications {
+			if notification.Digest && notification.ChatID != 0 && !slices.Contains(digestChats, notification.ChatID) {
+				digestChats = append(digestChats, notification.ChatID)
+			}
+			claimed = append(claimed, notification.ID)
+		}
+		if len(digestChats) > 0 {
+			var digests []entity.Notification
+			if err := tx.
+				Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Where(&entity.Notification{Status: entity.NotificationStatusPending, Digest: true}).
+				Where("next_attempt_at <= ?", now).
+				Where("chat_id IN ?", digestChats).
+				Where("id NOT IN ?", claimed).
+				Order("id ASC").
+				Find(&digests).Error; err != nil {
+				return err
+			}
+			notifications = append(notifications, digests...)
+		}
+		for k := range notifications {
+			notifications[k].Attempts++
+			notifications[k].NextAttemptAt = now.Add(lease)
+			if err := tx.Model(&notifications[k]).
+				Select("attempts", "next_attempt_at").
+				Updates(&notifications[k]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return notifications, err
+}
+
+// SaveNotification stores delivery result of the notification
+func (m *Manager) SaveNotification(notification *entity.Notification) error {
+	if err := m.Connect(); err != nil {
+		return err
+	}
+	return m.db.Save(notification).Error
+}
+
+// HasNewerEdit reports whether a later announcement edit of the same game and locale is queued or done,
+// so the edit with older text must not be applied
+func (m *Manager) HasNewerEdit(notification *entity.Notification) (bool, error) {
+	if err := m.Connect(); err != nil {
+		return false, err
+	}
+	var count int64
+	result := m.db.Model(&entity.Notification{}).
+		Where(&entity.Notification{GameID: notification.GameID, Edit: true, Locale: notification.Locale}).
+		Where("id > ?", notification.ID).
+		Where("status <> ?", entity.NotificationStatusDead).
+		Count(&count)
+	return count > 0, result.Error
+}
+
+// RescheduleNotifications applies changed settings of the user to private notifications waiting for the first
+// attempt, e.g. releases notifications held for the digest once the user turned it off
+func (m *Manager) RescheduleNotifications(settings *entity.UserSettings) error {
+	if err := m.Connect(); err != nil {
+		return err
+	}
+	return m.db.Model(&entity.Notification{}).
+		Where(&entity.Notification{ChatID: settings.TelegramID, Status: entity.NotificationStatusPending}).
+		Where("attempts = 0").
+		Updates(map[string]any{"next_attempt_at": settings.ReleaseAt(time.Now()), "digest": settings.Digest}).Error
+}
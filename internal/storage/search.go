@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"strings"
+	"time"
+
+	"github.com/kettari/location-bot/internal/entity"
+	"gorm.io/gorm/clause"
+)
+
+// searchConfig is the Postgres text search configuration; "russian" stems Cyrillic words
+// with the Russian snowball stemmer and Latin words with the English one
+const searchConfig = "russian"
+
+// MigrateSearch adds generated tsvector column with GIN index to the games table.
+// Title weighs most, then system and master, setting, and description
+func (m *Manager) MigrateSearch() error {
+	if err := m.Connect(); err != nil {
+		return err
+	}
+	statements := []string{
+		`ALTER TABLE loc_games ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+			setweight(to_tsvector('` + searchConfig + `', coalesce(title, '')), 'A') ||
+			setweight(to_tsvector('` + searchConfig + `', coalesce(system, '')), 'B') ||
+			setweight(to_tsvector('` + searchConfig + `', coalesce(master_name, '')), 'B') ||
+			setweight(to_tsvector('` + searchConfig + `', coalesce(setting, '')), 'C') ||
+			setweight(to_tsvector('` + searchConfig + `', coalesce(description, '')), 'D')
+		) STORED`,
+		`CREATE INDEX IF NOT EXISTS idx_loc_games_search_vector ON loc_games USING GIN (search_vector)`,
+	}
+	for _, statement := range statements {
+		if err := m.db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SearchGames returns games matching the web search style query ordered by rank, then by date.
+// Past games are skipped unless includePast is set
+func (m *Manager) SearchGames(query string, includePast bool, limit int) ([]entity.Game, error) {
+	if err := m.Connect(); err != nil {
+		return nil, err
+	}
+	query = strings.TrimSpace(query)
+	tsQuery := "websearch_to_tsquery('" + searchConfig + "', ?)"
+
+	db := m.db.Where("search_vector @@ "+tsQuery, query)
+	if !includePast {
+		db = db.Where("date > ?", time.Now())
+	}
+	var games []entity.Game
+	result := db.
+		Order(clause.Expr{SQL: "ts_rank(search_vector, " + tsQuery + ") DESC", Vars: []interface{}{query}}).
+		Order("date ASC").
+		Limit(limit).
+		Find(&games)
+	return games, result.Error
+}
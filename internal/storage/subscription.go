@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"github.com/kettari/location-bot/internal/entity"
+)
+
+// CreateSubscription stores new subscription of the user
+func (m *Manager) CreateSubscription(subscription *entity.Subscription) error {
+	if err := m.Connect(); err != nil {
+		return err
+	}
+	return m.db.Create(subscription).Error
+}
+
+// FindSubscriptions returns subscriptions of the Telegram user ordered by creation
+func (m *Manager) FindSubscriptions(telegramID int64) ([]entity.Subscription, error) {
+	if err := m.Connect(); err != nil {
+		return nil, err
+	}
+	var subscriptions []entity.Subscription
+	result := m.db.
+		Where(&entity.Subscription{TelegramID: telegramID}).
+		Order("id ASC").
+		Find(&subscriptions)
+	return subscriptions, result.Error
+}
+
+// AllSubscriptions returns subscriptions of all users
+func (m *Manager) AllSubscriptions() ([]entity.Subscription, error) {
+	if err := m.Connect(); err != nil {
+		return nil, err
+	}
+	var subscriptions []entity.Subscription
+	result := m.db.Order("id ASC").Find(&subscriptions)
+	return subscriptions, result.Error
+}
+
+// DeleteSubscription deletes subscription of the user by ID and returns number of deleted rows.
+// Zero subscriptionID deletes all subscriptions of the user
+func (m *Manager) DeleteSubscription(telegramID int64, subscriptionID uint) (int64, error) {
+	if err := m.Connect(); err != nil {
+		return 0, err
+	}
+	query := m.db.Where(&entity.Subscription{TelegramID: telegramID})
+	if subscriptionID > 0 {
+		query = query.Where("id = ?", subscriptionID)
+	}
+	result := query.Delete(&entity.Subscription{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"errors"
+
+	"github.com/kettari/location-bot/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FindUserSettings returns settings of the Telegram user; user without stored settings gets empty ones
+func (m *Manager) FindUserSettings(telegramID int64) (*entity.UserSettings, error) {
+	if err := m.Connect(); err != nil {
+		return nil, err
+	}
+	settings := &entity.UserSettings{TelegramID: telegramID}
+	result := m.db.Where(&entity.UserSettings{TelegramID: telegramID}).First(settings)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return settings, nil
+	}
+	return settings, result.Error
+}
+
+// SaveUserSettings creates or replaces settings of the user
+func (m *Manager) SaveUserSettings(settings *entity.UserSettings) error {
+	if err := m.Connect(); err != nil {
+		return err
+	}
+	return m.db.
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "telegram_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"locale", "language_code", "timezone", "quiet_from", "quiet_to", "digest", "updated_at"}),
+		}).
+		Create(settings).Error
+}
+
+// SaveLanguageCode stores Telegram client language of the user, creating settings if there are none; other
+// settings are kept as they are, so it does not overwrite changes made concurrently by /settings or /lang
+func (m *Manager) SaveLanguageCode(telegramID int64, languageCode string) error {
+	if err := m.Connect(); err != nil {
+		return err
+	}
+	return m.db.
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "telegram_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"language_code", "updated_at"}),
+		}).
+		Create(&entity.UserSettings{TelegramID: telegramID, LanguageCode: languageCode}).Error
+}
+
+// AllUserSettings maps Telegram ID to settings of every user with stored settings
+func (m *Manager) AllUserSettings() (map[int64]entity.UserSettings, error) {
+	if err := m.Connect(); err != nil {
+		return nil, err
+	}
+	var settings []entity.UserSettings
+	if result := m.db.Find(&settings); result.Error != nil {
+		return nil, result.Error
+	}
+	users := make(map[int64]entity.UserSettings, len(settings))
+	for k := range settings {
+		users[settings[k].TelegramID] = settings[k]
+	}
+	return users, nil
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/kettari/location-bot/internal/entity"
+)
+
+// CreateWatch stores interest of the user in the game; watching the same game twice is a no-op
+func (m *Manager) CreateWatch(telegramID int64, gameID uint) error {
+	if err := m.Connect(); err != nil {
+		return err
+	}
+	watch := entity.Watch{TelegramID: telegramID, GameID: gameID}
+	return m.db.Where(&watch).FirstOrCreate(&watch).Error
+}
+
+// DeleteWatch deletes watch of the user and returns number of deleted rows
+func (m *Manager) DeleteWatch(telegramID int64, gameID uint) (int64, error) {
+	if err := m.Connect(); err != nil {
+		return 0, err
+	}
+	result := m.db.
+		Where(&entity.Watch{TelegramID: telegramID, GameID: gameID}).
+		Delete(&entity.Watch{})
+	return result.RowsAffected, result.Error
+}
+
+// FindWatchedGames returns games watched by the Telegram user ordered by date
+func (m *Manager) FindWatchedGames(telegramID int64) ([]entity.Game, error) {
+	if err := m.Connect(); err != nil {
+		return nil, err
+	}
+	var games []entity.Game
+	result := m.db.
+		Joins("JOIN loc_watches ON loc_watches.game_id = loc_games.id AND loc_watches.deleted_at IS NULL").
+		Where("loc_watches.telegram_id = ?", telegramID).
+		Order("loc_games.date ASC").
+		Find(&games)
+	return games, result.Error
+}
+
+// WatchersByGame returns Telegram IDs of watchers grouped by game ID
+func (m *Manager) WatchersByGame() (map[uint][]int64, error) {
+	if err := m.Connect(); err != nil {
+		return nil, err
+	}
+	var watches []entity.Watch
+	if result := m.db.Find(&watches); result.Error != nil {
+		return nil, result.Error
+	}
+	watchers := make(map[uint][]int64)
+	for _, watch := range watches {
+		watchers[watch.GameID] = append(watchers[watch.GameID], watch.TelegramID)
+	}
+	return watchers, nil
+}
+
+// PurgeWatches deletes watches of past games and of games which external IDs are listed in cancelledExternalIDs
+func (m *Manager) PurgeWatches(cancelledExternalIDs []string) (int64, error) {
+	if err := m.Connect(); err != nil {
+		return 0, err
+	}
+	games := m.db.Model(&entity.Game{}).Select("id").Where("date <= ?", time.Now())
+	if len(cancelledExternalIDs) > 0 {
+		games = games.Or("external_id IN ?", cancelledExternalIDs)
+	}
+	result := m.db.Where("game_id IN (?)", games).Delete(&entity.Watch{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,259 @@
+// Package templates renders user-visible messages from text templates. Default templates of every locale
+// are embedded into the binary; templates with the same file names in the configured directory replace them
+package templates
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"html"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/kettari/location-bot/internal/i18n"
+)
+
+// Names of the templates, each one is the file "<name>.tmpl"
+const (
+	GameNew          = "game_new"
+	GameFreeSeats    = "game_free_seats"
+	GameCancelled    = "game_cancelled"
+	GameAnnouncement = "game_announcement"
+	GameCard         = "game_card"
+	ScheduleHeader   = "schedule_header"
+	ScheduleEmpty    = "schedule_empty"
+	ScheduleDate     = "schedule_date"
+	ScheduleGame     = "schedule_game"
+	ScheduleMore     = "schedule_more"
+	BoardUpdated     = "board_updated"
+	// partials defines templates shared by the others, e.g. "game_date" and "game_line"
+	partials = "_partials"
+)
+
+const extension = ".tmpl"
+
+// DefaultTimezone is the time zone of the club; dates are shown in it unless the reader chose another one
+const DefaultTimezone = "Europe/Moscow"
+
+//go:embed defaults/*/*.tmpl
+var defaultFiles embed.FS
+
+var (
+	locations   = map[string]*time.Location{}
+	locationsMu sync.Mutex
+)
+
+// LoadLocation returns the time zone by its IANA name, e.g. "Asia/Yekaterinburg"; loaded zones are cached
+func LoadLocation(name string) (*time.Location, error) {
+	locationsMu.Lock()
+	defer locationsMu.Unlock()
+	location, ok := locations[name]
+	if !ok {
+		var err error
+		if location, err = time.LoadLocation(name); err != nil {
+			return nil, err
+		}
+		locations[name] = location
+	}
+	return location, nil
+}
+
+// Funcs are helpers available in templates:
+//
+//	local .Date               — time in the time zone of the reader, [DefaultTimezone] by default
+//	in "Europe/Moscow" .Date  — time in the time zone
+//	weekday $date             — weekday name in upper case in the template locale, e.g. СУББОТА
+//	date $date, clock $date   — "02.01" and "15:04"
+//	escape .Title             — HTML escaping for Telegram HTML messages
+//	lower, upper              — case conversion
+var Funcs = template.FuncMap{
+	"local": func(t time.Time) (time.Time, error) {
+		location, err := LoadLocation(DefaultTimezone)
+		if err != nil {
+			return t, err
+		}
+		return t.In(location), nil
+	},
+	"in": func(name string, t time.Time) (time.Time, error) {
+		location, err := LoadLocation(name)
+		if err != nil {
+			return t, err
+		}
+		return t.In(location), nil
+	},
+	"weekday": func(t time.Time) string { return i18n.Weekday(i18n.Default, t.Weekday()) },
+	"date":    func(t time.Time) string { return t.Format("02.01") },
+	"clock":   func(t time.Time) string { return t.Format("15:04") },
+	"escape":  html.EscapeString,
+	"lower":   strings.ToLower,
+	"upper":   strings.ToUpper,
+}
+
+// funcs returns [Funcs] with weekday names of the locale
+func funcs(locale i18n.Locale) template.FuncMap {
+	result := template.FuncMap{}
+	for name, fn := range Funcs {
+		result[name] = fn
+	}
+	result["weekday"] = func(t time.Time) string { return i18n.Weekday(locale, t.Weekday()) }
+	return result
+}
+
+// Set is the loaded templates of all locales
+type Set struct {
+	templates map[i18n.Locale]*template.Template
+	// defaults render the message when the custom template fails
+	defaults map[i18n.Locale]*template.Template
+	// Custom lists names of templates loaded from the directory, prefixed with the locale, e.g. "en/game_new"
+	Custom []string
+}
+
+var (
+	current   *Set
+	currentMu sync.RWMutex
+)
+
+// Load parses embedded templates and replaces them with files of the directory, if set. Templates of the locale
+// are read from its subdirectory, e.g. "en/game_new.tmpl"; files in the directory itself replace templates
+// of the default locale. Files with unknown names are rejected to catch misspelled ones
+func Load(dir string) (*Set, error) {
+	set := &Set{templates: map[i18n.Locale]*template.Template{}, defaults: map[i18n.Locale]*template.Template{}}
+	for _, locale := range i18n.Locales() {
+		defaults, err := template.New("").Funcs(funcs(locale)).ParseFS(defaultFiles, "defaults/"+string(locale)+"/*"+extension)
+		if err != nil {
+			return nil, err
+		}
+		set.templates[locale], set.defaults[locale] = defaults, defaults
+		if len(dir) == 0 {
+			continue
+		}
+
+		localeDir := filepath.Join(dir, string(locale))
+		if locale == i18n.Default {
+			if localeDir, err = defaultLocaleDir(dir); err != nil {
+				return nil, err
+			}
+		}
+		files, err := filepath.Glob(filepath.Join(localeDir, "*"+extension))
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			continue
+		}
+		if set.templates[locale], err = defaults.Clone(); err != nil {
+			return nil, err
+		}
+		names := Names()
+		for _, file := range files {
+			name := strings.TrimSuffix(filepath.Base(file), extension)
+			if name != partials && !slices.Contains(names, name) {
+				return nil, fmt.Errorf("unknown template %s, expected one of %s", file, strings.Join(names, ", "))
+			}
+			text, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			if _, err = set.templates[locale].New(name + extension).Parse(string(text)); err != nil {
+				return nil, err
+			}
+			set.Custom = append(set.Custom, string(locale)+"/"+name)
+		}
+	}
+	return set, nil
+}
+
+// defaultLocaleDir returns the subdirectory of the default locale if it exists, otherwise the directory itself
+func defaultLocaleDir(dir string) (string, error) {
+	localeDir := filepath.Join(dir, string(i18n.Default))
+	info, err := os.Stat(localeDir)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && !info.IsDir()) {
+		return dir, nil
+	}
+	return localeDir, err
+}
+
+// Names returns names of the templates which render messages
+func Names() []string {
+	return []string{GameNew, GameFreeSeats, GameCancelled, GameAnnouncement, GameCard,
+		ScheduleHeader, ScheduleEmpty, ScheduleDate, ScheduleGame, ScheduleMore, BoardUpdated}
+}
+
+// Execute renders the template of the locale; trailing line breaks of the template file are dropped
+func (s *Set) Execute(locale i18n.Locale, name string, data any) (string, error) {
+	return s.ExecuteIn(locale, nil, name, data)
+}
+
+// ExecuteIn renders the template of the locale with dates in the time zone; nil location means [DefaultTimezone]
+func (s *Set) ExecuteIn(locale i18n.Locale, location *time.Location, name string, data any) (string, error) {
+	templates, ok := s.templates[locale]
+	if !ok {
+		return "", fmt.Errorf("unsupported locale %q", locale)
+	}
+	return execute(templates, location, name, data)
+}
+
+func execute(templates *template.Template, location *time.Location, name string, data any) (string, error) {
+	if location != nil && location.String() != DefaultTimezone {
+		// Clone has own functions, so concurrent renders in other time zones do not interfere
+		var err error
+		if templates, err = templates.Clone(); err != nil {
+			return "", err
+		}
+		templates.Funcs(template.FuncMap{"local": func(t time.Time) time.Time { return t.In(location) }})
+	}
+	var buffer bytes.Buffer
+	if err := templates.ExecuteTemplate(&buffer, name+extension, data); err != nil {
+		return "", err
+	}
+	return strings.TrimRight(buffer.String(), "\n"), nil
+}
+
+// Use makes the set render messages of [Render]
+func Use(set *Set) {
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	current = set
+}
+
+// Render renders the message in the locale with the templates set by [Use] or embedded ones. Custom template
+// which fails is logged and replaced with the embedded one, so a mistake in it does not stop notifications.
+// Unsupported locale is replaced with the default one
+func Render(locale i18n.Locale, name string, data any) string {
+	return RenderIn(locale, nil, name, data)
+}
+
+// RenderIn renders the message like [Render] with dates in the time zone; nil location means [DefaultTimezone]
+func RenderIn(locale i18n.Locale, location *time.Location, name string, data any) string {
+	currentMu.RLock()
+	set := current
+	currentMu.RUnlock()
+	if set == nil {
+		var err error
+		if set, err = Load(""); err != nil {
+			panic(err)
+		}
+		Use(set)
+	}
+
+	if _, ok := set.templates[locale]; !ok {
+		locale = i18n.Default
+	}
+	text, err := set.ExecuteIn(locale, location, name, data)
+	if err == nil {
+		return text
+	}
+	slog.Error("failed to render template, using embedded one", "template", name, "locale", locale, "error", err)
+	if text, err = execute(set.defaults[locale], location, name, data); err != nil {
+		slog.Error("failed to render embedded template", "template", name, "locale", locale, "error", err)
+	}
+	return text
+}
@@ -0,0 +1,159 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kettari/location-bot/internal/i18n"
+)
+
+type testGame struct {
+	Title, URL, System, Setting string
+	Date                        time.Time
+	SeatsFree, SeatsTotal       int
+}
+
+var sampleGame = testGame{
+	Title:      "Клинки & кинжалы",
+	URL:        "https://rolecon.ru/game/1",
+	System:     "Blades in the Dark",
+	Setting:    "Дусквол",
+	Date:       time.Date(2025, 11, 1, 16, 0, 0, 0, time.UTC),
+	SeatsFree:  2,
+	SeatsTotal: 5,
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	set, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	got, err := set.Execute(i18n.Russian, GameNew, sampleGame)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	want := "<b>СУББОТА</b> (01.11, 19:00)\n2/5 <a href=\"https://rolecon.ru/game/1\">Клинки &amp; кинжалы</a> [Blades in the Dark; Дусквол]"
+	if got != want {
+		t.Errorf("Execute() = %q, want %q", got, want)
+	}
+	if got, _ = set.Execute(i18n.Russian, ScheduleMore, 3); got != "…и ещё игр: 3" {
+		t.Errorf("Execute(%s) = %q", ScheduleMore, got)
+	}
+	if got, _ = set.Execute(i18n.English, GameCard, struct {
+		testGame
+		MasterName, MasterLink, Genre, ShortDescription, ShortNotes string
+	}{testGame: sampleGame}); !strings.HasPrefix(got, "<b>Клинки &amp; кинжалы</b>\n\nsaturday, 01.11, 19:00\nFree seats: 2/5") {
+		t.Errorf("Execute(%s) in English = %q", GameCard, got)
+	}
+}
+
+func TestSet_ExecuteIn(t *testing.T) {
+	set, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	yekaterinburg, err := LoadLocation("Asia/Yekaterinburg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := set.ExecuteIn(i18n.Russian, yekaterinburg, ScheduleDate, sampleGame); got != "<b>СУББОТА</b> (01.11, 21:00)" {
+		t.Errorf("ExecuteIn() = %q, want date in the time zone", got)
+	}
+	// Rendering in another time zone does not change the default one
+	if got, _ := set.Execute(i18n.Russian, ScheduleDate, sampleGame); got != "<b>СУББОТА</b> (01.11, 19:00)" {
+		t.Errorf("Execute() = %q, want date in the default time zone", got)
+	}
+}
+
+func TestLoad_Directory(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "_partials", `{{ define "game_date" }}{{ date (in "Asia/Yekaterinburg" .Date) }}{{ end }}`)
+	writeTemplate(t, dir, GameCancelled, "Отмена: {{ escape .Title }}\n")
+
+	set, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got, _ := set.Execute(i18n.Russian, GameCancelled, sampleGame); got != "Отмена: Клинки &amp; кинжалы" {
+		t.Errorf("custom template = %q", got)
+	}
+	// Embedded template uses the custom partial
+	if got, _ := set.Execute(i18n.Russian, ScheduleDate, sampleGame); got != "01.11" {
+		t.Errorf("template with custom partial = %q", got)
+	}
+	if len(set.Custom) != 2 {
+		t.Errorf("Custom = %v, want 2 templates", set.Custom)
+	}
+	// Other locales keep embedded templates
+	if got, _ := set.Execute(i18n.English, GameCancelled, sampleGame); !strings.HasPrefix(got, "Game cancelled:") {
+		t.Errorf("template of other locale = %q", got)
+	}
+}
+
+func TestLoad_LocaleDirectories(t *testing.T) {
+	dir := t.TempDir()
+	for _, locale := range []string{"ru", "en"} {
+		if err := os.Mkdir(filepath.Join(dir, locale), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeTemplate(t, filepath.Join(dir, "ru"), ScheduleEmpty, "Пусто")
+	writeTemplate(t, filepath.Join(dir, "en"), ScheduleEmpty, "Empty")
+	// Files next to locale directories are ignored when the default locale has its own directory
+	writeTemplate(t, dir, ScheduleEmpty, "Ignored")
+
+	set, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	for locale, want := range map[i18n.Locale]string{i18n.Russian: "Пусто", i18n.English: "Empty"} {
+		if got, _ := set.Execute(locale, ScheduleEmpty, nil); got != want {
+			t.Errorf("Execute(%s) = %q, want %q", locale, got, want)
+		}
+	}
+	if _, err = set.Execute("de", ScheduleEmpty, nil); err == nil {
+		t.Error("Execute() of unsupported locale error = nil")
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	unknown := t.TempDir()
+	writeTemplate(t, unknown, "game_nwe", "typo")
+	if _, err := Load(unknown); err == nil || !strings.Contains(err.Error(), "unknown template") {
+		t.Errorf("Load() error = %v, want unknown template", err)
+	}
+
+	broken := t.TempDir()
+	writeTemplate(t, broken, GameNew, "{{ .Title ")
+	if _, err := Load(broken); err == nil {
+		t.Error("Load() error = nil, want parse error")
+	}
+}
+
+func TestRender_FallbackToEmbedded(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, GameNew, "{{ .Master }}")
+	set, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Use(set)
+	defer Use(nil)
+
+	if got := Render(i18n.Russian, GameNew, sampleGame); !strings.Contains(got, "СУББОТА") {
+		t.Errorf("Render() = %q, want embedded template output", got)
+	}
+	if got := Render("de", GameNew, sampleGame); !strings.Contains(got, "СУББОТА") {
+		t.Errorf("Render() of unsupported locale = %q, want default locale output", got)
+	}
+}
+
+func writeTemplate(t *testing.T, dir, name, text string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name+extension), []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,57 @@
+package transport
+
+import (
+	"net/http"
+	"strings"
+)
+
+// discordContentLimit is the maximum length of the Discord message
+const discordContentLimit = 2000
+
+// Discord posts notification to the Discord incoming webhook, splitting long parts into several messages
+type Discord struct {
+	name     string
+	URL      string
+	Renderer Renderer
+	client   *http.Client
+}
+
+type discordPayload struct {
+	Content string `json:"content"`
+	// AllowedMentions disables pings of @everyone and roles mentioned in game descriptions
+	AllowedMentions map[string][]string `json:"allowed_mentions"`
+}
+
+func (d *Discord) Name() string {
+	return d.name
+}
+
+// Send implements [entity.MessageDispatcher]
+func (d *Discord) Send(notification []string) error {
+	for _, part := range notification {
+		for _, content := range SplitText(d.Renderer.Render(part), discordContentLimit) {
+			if err := sendJSON(d.client, d.name, http.MethodPost, d.URL, nil, discordPayload{
+				Content:         content,
+				AllowedMentions: map[string][]string{"parse": {}},
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SplitText splits text into chunks not longer than limit characters, preferably on line breaks
+func SplitText(text string, limit int) []string {
+	var chunks []string
+	for len([]rune(text)) > limit {
+		runes := []rune(text)
+		cut := strings.LastIndex(string(runes[:limit]), "\n")
+		if cut <= 0 {
+			cut = len(string(runes[:limit]))
+		}
+		chunks = append(chunks, text[:cut])
+		text = strings.TrimPrefix(text[cut:], "\n")
+	}
+	return append(chunks, text)
+}
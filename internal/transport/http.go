@@ -0,0 +1,49 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// sendJSON sends payload and converts failed response to [Error]: 4xx except 408 and 429 are permanent
+func sendJSON(client *http.Client, name, method, url string, header http.Header, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return &Error{Transport: name, Err: err, Permanent: true}
+	}
+	request, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return &Error{Transport: name, Err: err, Permanent: true}
+	}
+	for key, values := range header {
+		request.Header[key] = values
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := client.Do(request)
+	if err != nil {
+		return &Error{Transport: name, Err: err}
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		_, _ = io.Copy(io.Discard, response.Body)
+		return nil
+	}
+
+	text, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+	transportErr := &Error{
+		Transport: name,
+		Err:       fmt.Errorf("unexpected status %s: %s", response.Status, bytes.TrimSpace(text)),
+		Permanent: response.StatusCode >= 400 && response.StatusCode < 500 &&
+			response.StatusCode != http.StatusRequestTimeout && response.StatusCode != http.StatusTooManyRequests,
+	}
+	if seconds, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		transportErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return transportErr
+}
@@ -0,0 +1,78 @@
+package transport
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Matrix sends notification to the Matrix room through the client-server API
+type Matrix struct {
+	name         string
+	Homeserver   string
+	RoomID       string
+	AccessToken  string
+	Renderer     Renderer
+	HTMLRenderer Renderer
+	client       *http.Client
+}
+
+type matrixMessage struct {
+	MsgType       string `json:"msgtype"`
+	Body          string `json:"body"`
+	Format        string `json:"format"`
+	FormattedBody string `json:"formatted_body"`
+}
+
+func newMatrix(name string, endpoint *url.URL) (*Matrix, error) {
+	query := endpoint.Query()
+	room, token := query.Get("room"), query.Get("token")
+	if len(room) == 0 || len(token) == 0 {
+		return nil, errors.New("matrix transport requires room and token parameters")
+	}
+	homeserver := url.URL{Scheme: endpoint.Scheme, Host: endpoint.Host, Path: strings.TrimSuffix(endpoint.Path, "/")}
+	return &Matrix{
+		name:         name,
+		Homeserver:   homeserver.String(),
+		RoomID:       room,
+		AccessToken:  token,
+		Renderer:     PlainRenderer{},
+		HTMLRenderer: HTMLRenderer{},
+		client:       newHTTPClient(),
+	}, nil
+}
+
+func (m *Matrix) Name() string {
+	return m.name
+}
+
+// Send implements [entity.MessageDispatcher], one room message per part; repeated call sends the notification again
+func (m *Matrix) Send(notification []string) error {
+	key := make([]byte, 16)
+	_, _ = rand.Read(key)
+	return m.SendIdempotent(hex.EncodeToString(key), notification)
+}
+
+// SendIdempotent implements [IdempotentTransport]: transaction ID of the part is derived from the key and
+// the part index, so the homeserver ignores the part sent again after a failed attempt
+func (m *Matrix) SendIdempotent(key string, notification []string) error {
+	header := http.Header{"Authorization": {"Bearer " + m.AccessToken}}
+	for k, part := range notification {
+		txnID := fmt.Sprintf("locbot.%s.%d", key, k)
+		endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+			m.Homeserver, url.PathEscape(m.RoomID), url.PathEscape(txnID))
+		if err := sendJSON(m.client, m.name, http.MethodPut, endpoint, header, matrixMessage{
+			MsgType:       "m.notice",
+			Body:          m.Renderer.Render(part),
+			Format:        "org.matrix.custom.html",
+			FormattedBody: m.HTMLRenderer.Render(part),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
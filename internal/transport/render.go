@@ -0,0 +1,155 @@
+package transport
+
+import (
+	"errors"
+	"html"
+	"io"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+)
+
+// Renderer converts notification in Telegram HTML (b, i, s, code, a tags) to the transport format
+type Renderer interface {
+	Render(text string) string
+}
+
+// markup describes the target format of [render]
+type markup struct {
+	bold, italic, strike, code [2]string
+	escape                     func(text string) string
+	link                       func(url, text string) string
+}
+
+// PlainRenderer drops formatting and prints links as "text (url)"
+type PlainRenderer struct{}
+
+func (PlainRenderer) Render(text string) string {
+	return render(text, markup{
+		escape: func(text string) string { return text },
+		link: func(url, text string) string {
+			if len(url) == 0 || text == url {
+				return text + url
+			}
+			return text + " (" + url + ")"
+		},
+	})
+}
+
+// HTMLRenderer keeps formatting as standard HTML with line breaks, e.g. for e-mail and Matrix
+type HTMLRenderer struct{}
+
+func (HTMLRenderer) Render(text string) string {
+	return render(text, markup{
+		bold:   [2]string{"<b>", "</b>"},
+		italic: [2]string{"<i>", "</i>"},
+		strike: [2]string{"<s>", "</s>"},
+		code:   [2]string{"<code>", "</code>"},
+		escape: func(text string) string {
+			return strings.ReplaceAll(html.EscapeString(text), "\n", "<br>\n")
+		},
+		link: func(url, text string) string {
+			return "<a href=\"" + html.EscapeString(url) + "\">" + text + "</a>"
+		},
+	})
+}
+
+// MarkdownRenderer produces Discord flavoured Markdown
+type MarkdownRenderer struct{}
+
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "~", `\~`, "`", "\\`", "|", `\|`, "[", `\[`, "]", `\]`)
+
+func (MarkdownRenderer) Render(text string) string {
+	return render(text, markup{
+		bold:   [2]string{"**", "**"},
+		italic: [2]string{"*", "*"},
+		strike: [2]string{"~~", "~~"},
+		code:   [2]string{"`", "`"},
+		escape: markdownEscaper.Replace,
+		link: func(url, text string) string {
+			if len(url) == 0 {
+				return text
+			}
+			return "[" + text + "](<" + url + ">)"
+		},
+	})
+}
+
+// SlackRenderer produces Slack mrkdwn
+type SlackRenderer struct{}
+
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func (SlackRenderer) Render(text string) string {
+	return render(text, markup{
+		bold:   [2]string{"*", "*"},
+		italic: [2]string{"_", "_"},
+		strike: [2]string{"~", "~"},
+		code:   [2]string{"`", "`"},
+		escape: slackEscaper.Replace,
+		link: func(url, text string) string {
+			if len(url) == 0 {
+				return text
+			}
+			return "<" + url + "|" + strings.ReplaceAll(text, "|", "¦") + ">"
+		},
+	})
+}
+
+// render walks Telegram HTML and writes it in the target markup; unknown tags are dropped, their text is kept
+func render(text string, m markup) string {
+	tokenizer := nethtml.NewTokenizer(strings.NewReader(text))
+	var result, linkText strings.Builder
+	out := &result
+	linkURL := ""
+
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case nethtml.ErrorToken:
+			if err := tokenizer.Err(); err != nil && !errors.Is(err, io.EOF) {
+				return result.String()
+			}
+			if out == &linkText {
+				result.WriteString(m.link(linkURL, linkText.String()))
+			}
+			return result.String()
+		case nethtml.TextToken:
+			out.WriteString(m.escape(string(tokenizer.Text())))
+		case nethtml.StartTagToken, nethtml.EndTagToken:
+			name, hasAttr := tokenizer.TagName()
+			side := 0
+			if tokenType == nethtml.EndTagToken {
+				side = 1
+			}
+			switch string(name) {
+			case "b", "strong":
+				out.WriteString(m.bold[side])
+			case "i", "em":
+				out.WriteString(m.italic[side])
+			case "s", "strike", "del":
+				out.WriteString(m.strike[side])
+			case "code", "pre":
+				out.WriteString(m.code[side])
+			case "br":
+				out.WriteString(m.escape("\n"))
+			case "a":
+				if side == 0 {
+					linkURL = ""
+					for hasAttr {
+						var key, value []byte
+						key, value, hasAttr = tokenizer.TagAttr()
+						if string(key) == "href" {
+							linkURL = string(value)
+						}
+					}
+					linkText.Reset()
+					out = &linkText
+				} else if out == &linkText {
+					result.WriteString(m.link(linkURL, linkText.String()))
+					out = &result
+				}
+			}
+		}
+	}
+}
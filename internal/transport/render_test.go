@@ -0,0 +1,42 @@
+package transport
+
+import "testing"
+
+const renderSample = `🆕 <b>Новая игра</b> <a href="https://rolecon.ru/event/1">Тени &amp; свет</a>
+<i>Pathfinder</i> 2*3 <s>старое</s>`
+
+func TestRenderers(t *testing.T) {
+	tests := []struct {
+		name     string
+		renderer Renderer
+		want     string
+	}{
+		{
+			name:     "plain",
+			renderer: PlainRenderer{},
+			want:     "🆕 Новая игра Тени & свет (https://rolecon.ru/event/1)\nPathfinder 2*3 старое",
+		},
+		{
+			name:     "html",
+			renderer: HTMLRenderer{},
+			want:     "🆕 <b>Новая игра</b> <a href=\"https://rolecon.ru/event/1\">Тени &amp; свет</a><br>\n<i>Pathfinder</i> 2*3 <s>старое</s>",
+		},
+		{
+			name:     "markdown",
+			renderer: MarkdownRenderer{},
+			want:     "🆕 **Новая игра** [Тени & свет](<https://rolecon.ru/event/1>)\n*Pathfinder* 2\\*3 ~~старое~~",
+		},
+		{
+			name:     "slack",
+			renderer: SlackRenderer{},
+			want:     "🆕 *Новая игра* <https://rolecon.ru/event/1|Тени &amp; свет>\n_Pathfinder_ 2*3 ~старое~",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.renderer.Render(renderSample); got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package transport
+
+import (
+	"net/http"
+)
+
+// Slack posts notification to the Slack incoming webhook, one message per part
+type Slack struct {
+	name     string
+	URL      string
+	Renderer Renderer
+	client   *http.Client
+}
+
+type slackPayload struct {
+	Text        string `json:"text"`
+	UnfurlLinks bool   `json:"unfurl_links"`
+}
+
+func (s *Slack) Name() string {
+	return s.name
+}
+
+// Send implements [entity.MessageDispatcher]
+func (s *Slack) Send(notification []string) error {
+	for _, part := range notification {
+		if err := sendJSON(s.client, s.name, http.MethodPost, s.URL, nil, slackPayload{
+			Text: s.Renderer.Render(part),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package transport
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// defaultMailSubject is used when the smtp URL has no subject parameter
+const defaultMailSubject = "Игры в Локации"
+
+// SMTP sends notification as the e-mail with plain text and HTML alternatives.
+// From and To may have display names like "Bot <bot@example.org>": they go to the message headers,
+// while the SMTP envelope gets bare addresses
+type SMTP struct {
+	name         string
+	Address      string
+	Auth         smtp.Auth
+	From         string
+	To           []string
+	Subject      string
+	Renderer     Renderer
+	HTMLRenderer Renderer
+	// sendMail is replaced in tests
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func newSMTP(name string, endpoint *url.URL) (*SMTP, error) {
+	query := endpoint.Query()
+	from := query.Get("from")
+	if _, err := mail.ParseAddress(from); err != nil {
+		return nil, fmt.Errorf("smtp transport has invalid from address %q: %w", from, err)
+	}
+	var to []string
+	for _, address := range strings.Split(query.Get("to"), ",") {
+		address = strings.TrimSpace(address)
+		if len(address) == 0 {
+			continue
+		}
+		if _, err := mail.ParseAddress(address); err != nil {
+			return nil, fmt.Errorf("smtp transport has invalid to address %q: %w", address, err)
+		}
+		to = append(to, address)
+	}
+	if len(to) == 0 {
+		return nil, errors.New("smtp transport requires to parameter")
+	}
+	subject := query.Get("subject")
+	if len(subject) == 0 {
+		subject = defaultMailSubject
+	}
+
+	address := endpoint.Host
+	if len(endpoint.Port()) == 0 {
+		address = net.JoinHostPort(endpoint.Hostname(), "587")
+	}
+	transport := &SMTP{
+		name:         name,
+		Address:      address,
+		From:         from,
+		To:           to,
+		Subject:      subject,
+		Renderer:     PlainRenderer{},
+		HTMLRenderer: HTMLRenderer{},
+		sendMail:     smtp.SendMail,
+	}
+	if endpoint.User != nil {
+		password, _ := endpoint.User.Password()
+		transport.Auth = smtp.PlainAuth("", endpoint.User.Username(), password, endpoint.Hostname())
+	}
+	return transport, nil
+}
+
+func (s *SMTP) Name() string {
+	return s.name
+}
+
+// Send implements [entity.MessageDispatcher], all parts go in a single e-mail
+func (s *SMTP) Send(notification []string) error {
+	from, to, err := s.addresses()
+	if err != nil {
+		return &Error{Transport: s.name, Err: err, Permanent: true}
+	}
+	message, err := s.message(from, to, notification, time.Now())
+	if err != nil {
+		return &Error{Transport: s.name, Err: err, Permanent: true}
+	}
+	envelopeTo := make([]string, 0, len(to))
+	for _, recipient := range to {
+		envelopeTo = append(envelopeTo, recipient.Address)
+	}
+	if err = s.sendMail(s.Address, s.Auth, from.Address, envelopeTo, message); err != nil {
+		// 5xx replies are permanent failures in SMTP
+		var protocolErr *textproto.Error
+		return &Error{Transport: s.name, Err: err, Permanent: errors.As(err, &protocolErr) && protocolErr.Code >= 500}
+	}
+	return nil
+}
+
+// addresses parses the sender and the recipients
+func (s *SMTP) addresses() (*mail.Address, []*mail.Address, error) {
+	from, err := mail.ParseAddress(s.From)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid from address %q: %w", s.From, err)
+	}
+	to := make([]*mail.Address, 0, len(s.To))
+	for _, address := range s.To {
+		recipient, err := mail.ParseAddress(address)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid to address %q: %w", address, err)
+		}
+		to = append(to, recipient)
+	}
+	return from, to, nil
+}
+
+// message builds multipart/alternative MIME message
+func (s *SMTP) message(from *mail.Address, to []*mail.Address, notification []string, now time.Time) ([]byte, error) {
+	var text, html []string
+	for _, part := range notification {
+		text = append(text, s.Renderer.Render(part))
+		html = append(html, s.HTMLRenderer.Render(part))
+	}
+
+	random := make([]byte, 12)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	boundary := hex.EncodeToString(random)
+
+	var buffer bytes.Buffer
+	header := func(key, value string) {
+		buffer.WriteString(key + ": " + value + "\r\n")
+	}
+	recipients := make([]string, 0, len(to))
+	for _, recipient := range to {
+		recipients = append(recipients, recipient.String())
+	}
+	header("From", from.String())
+	header("To", strings.Join(recipients, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", s.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", boundary))
+	buffer.WriteString("\r\n")
+
+	for _, alternative := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", strings.Join(text, "\n\n")},
+		{"text/html; charset=utf-8", "<html><body>" + strings.Join(html, "<br>\n<br>\n") + "</body></html>"},
+	} {
+		buffer.WriteString("--" + boundary + "\r\n")
+		header("Content-Type", alternative.contentType)
+		header("Content-Transfer-Encoding", "base64")
+		buffer.WriteString("\r\n")
+		encoded := base64.StdEncoding.EncodeToString([]byte(alternative.body))
+		for len(encoded) > 76 {
+			buffer.WriteString(encoded[:76] + "\r\n")
+			encoded = encoded[76:]
+		}
+		buffer.WriteString(encoded + "\r\n")
+	}
+	buffer.WriteString("--" + boundary + "--\r\n")
+	return buffer.Bytes(), nil
+}
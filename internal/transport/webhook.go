@@ -0,0 +1,37 @@
+package transport
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Webhook posts notification as JSON {"text": "...", "html": "..."} to the generic HTTP endpoint
+type Webhook struct {
+	name         string
+	URL          string
+	Renderer     Renderer
+	HTMLRenderer Renderer
+	client       *http.Client
+}
+
+type webhookPayload struct {
+	Text string `json:"text"`
+	HTML string `json:"html"`
+}
+
+func (w *Webhook) Name() string {
+	return w.name
+}
+
+// Send implements [entity.MessageDispatcher], all parts go in a single request
+func (w *Webhook) Send(notification []string) error {
+	var text, html []string
+	for _, part := range notification {
+		text = append(text, w.Renderer.Render(part))
+		html = append(html, w.HTMLRenderer.Render(part))
+	}
+	return sendJSON(w.client, w.name, http.MethodPost, w.URL, nil, webhookPayload{
+		Text: strings.Join(text, "\n\n"),
+		HTML: strings.Join(html, "<br>\n<br>\n"),
+	})
+}